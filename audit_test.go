@@ -0,0 +1,160 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"hypherledgertest2/model"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// auditAll runs the audit page by page until the last page.
+// Returns the last page and the issues of every page.
+func auditAll(t *testing.T, f *erc20Fixture, pageSize int) (model.AuditReport, []model.AuditIssue, int) {
+	issues := []model.AuditIssue{}
+	bookmark := ""
+	for pages := 1; ; pages++ {
+		report := model.AuditReport{}
+		err := json.Unmarshal(f.mustInvoke("", "audit", "token", strconv.Itoa(pageSize), bookmark), &report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Issues) > pageSize+1 {
+			t.Fatalf("page of %d entries has %d issues", pageSize, len(report.Issues))
+		}
+		issues = append(issues, report.Issues...)
+
+		if report.Complete != (report.Bookmark == "") {
+			t.Fatalf("only the last page is complete, got %+v", report)
+		}
+		if report.Complete {
+			return report, issues, pages
+		}
+		bookmark = report.Bookmark
+	}
+}
+
+func TestAuditConsistentLedger(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	bobAddress := f.address("bob")
+	f.mustInvoke("", "transfer", "owner", "alice", "300")
+	f.mustInvoke("", "transfer", "alice", bobAddress, "100")
+	f.mustInvoke("", "approve", "owner", "alice", "50")
+	f.mustInvoke("", "approve", "alice", bobAddress, "20")
+	f.mustInvoke("bob", "burn", "token", "40")
+
+	for _, pageSize := range []int{1, 2, 5, 100} {
+		report, issues, pages := auditAll(t, f, pageSize)
+		if !report.Consistent || len(issues) != 0 {
+			t.Fatalf("page size %d: expected the consistent ledger, got %+v %+v", pageSize, report, issues)
+		}
+		if report.TotalSupply != 960 || report.BalanceSum != 960 {
+			t.Fatalf("page size %d: expected the sum 960, got %+v", pageSize, report)
+		}
+		if report.BalanceCount != 3 || report.AllowanceCount != 2 {
+			t.Fatalf("page size %d: expected 3 balances and 2 allowances, got %+v", pageSize, report)
+		}
+		if expected := (5 + pageSize - 1) / pageSize; pages != expected {
+			t.Fatalf("page size %d: expected %d pages, got %d", pageSize, expected, pages)
+		}
+	}
+}
+
+func TestAuditReportsIssues(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	f.mustInvoke("", "approve", "owner", "spender", "100")
+
+	stub := f.ledger.MockStub()
+	stub.MockTransactionStart("corrupt")
+	approvalKey := func(owner, spender string) string {
+		key, _ := stub.CreateCompositeKey("approval", []string{owner, spender})
+		return key
+	}
+	stub.PutState("malformed", []byte("abc"))
+	stub.PutState("negative", []byte("-5"))
+	stub.PutState(approvalKey("owner", "negative"), []byte(`{"amount":-1}`))
+	stub.PutState(approvalKey("owner", "malformed"), []byte("{"))
+	stub.PutState(approvalKey("ghost", "spender"), []byte("10"))
+	stub.MockTransactionEnd("corrupt")
+
+	report, issues, _ := auditAll(t, f, 2)
+	if report.Consistent {
+		t.Fatal("the corrupted ledger must not be consistent")
+	}
+	if report.BalanceSum != 995 || report.BalanceCount != 3 || report.AllowanceCount != 4 {
+		t.Fatalf("expected the sum 995 of 3 balances and 4 allowances, got %+v", report)
+	}
+
+	found := map[string]string{}
+	for _, issue := range issues {
+		found[issue.Type] = issue.Key
+	}
+	expected := map[string]string{
+		model.MalformedBalanceIssue:   "malformed",
+		model.NegativeBalanceIssue:    "negative",
+		model.NegativeAllowanceIssue:  approvalKey("owner", "negative"),
+		model.MalformedAllowanceIssue: approvalKey("owner", "malformed"),
+		model.OrphanedAllowanceIssue:  approvalKey("ghost", "spender"),
+		model.SupplyMismatchIssue:     "token",
+	}
+	if len(issues) != len(expected) || report.IssueCount != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %+v", len(expected), report.IssueCount, issues)
+	}
+	for issueType, key := range expected {
+		if found[issueType] != key {
+			t.Fatalf("expected %s of %q, got %+v", issueType, key, issues)
+		}
+	}
+}
+
+func TestAuditInvalidParams(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+
+	for _, params := range [][]string{
+		{"token", "0", ""},
+		{"token", "10", "not a bookmark"},
+		{"other", "10", ""},
+		{"token", "10"},
+	} {
+		if res := f.invoke("", "audit", params...); res.Status == shim.OK {
+			t.Fatalf("audit%q must fail", params)
+		}
+	}
+}
+
+func TestAuditIgnoresForgedBookmark(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	f.mustInvoke("", "approve", "owner", "spender", "100")
+
+	stub := f.ledger.MockStub()
+	stub.MockTransactionStart("corrupt")
+	stub.PutState("negative", []byte("-5"))
+	approvalKey, _ := stub.CreateCompositeKey("approval", []string{"owner", "spender"})
+	stub.MockTransactionEnd("corrupt")
+
+	// the forged bookmark skips to the last page claiming the consistent sum of the previous pages
+	cursorBytes, _ := json.Marshal(map[string]interface{}{
+		"key": approvalKey, "balanceSum": 1000, "balanceCount": 2, "issueCount": 0})
+	bookmark := base64.RawURLEncoding.EncodeToString(cursorBytes)
+
+	report := model.AuditReport{}
+	if err := json.Unmarshal(f.mustInvoke("", "audit", "token", "1", bookmark), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Complete || report.Consistent {
+		t.Fatalf("the forged bookmark must not make the corrupted ledger consistent, got %+v", report)
+	}
+	if report.BalanceSum != 995 || report.BalanceCount != 2 || report.IssueCount != 2 {
+		t.Fatalf("expected the recomputed sum 995 of 2 balances and 2 issues, got %+v", report)
+	}
+}
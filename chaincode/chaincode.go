@@ -0,0 +1,209 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package chaincode is the ERC20 token chaincode dispatching the functions to the controller.
+package chaincode
+
+import (
+	"fmt"
+	"hypherledgertest2/controller"
+	"hypherledgertest2/fabric"
+)
+
+// ERC20Chaincode is the definition of the chaincode structure.
+type ERC20Chaincode struct {
+	controller *controller.Controller
+}
+
+// NewChaincode is ...
+func NewChaincode() *ERC20Chaincode {
+	controller := controller.NewController()
+	return &ERC20Chaincode{controller}
+}
+
+// Init is called when the chaincode is instantiated by the blockchain network.
+// params : tokenName, symbol, owner(address), amount
+func (cc *ERC20Chaincode) Init(stub fabric.Stub) fabric.Response {
+	_, params := stub.GetFunctionAndParameters()
+	fmt.Println("Init is called with params:", params)
+
+	return cc.InitToken(stub, params)
+}
+
+// InitToken initializes the token, or keeps the state and migrates it on the upgrade, /
+// shared by Init and the contract API.
+// params : tokenName, symbol, owner(address), amount
+func (cc *ERC20Chaincode) InitToken(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 4 {
+		return fabric.Error("incorrect number of the params")
+	}
+
+	return cc.controller.Init(stub, params)
+}
+
+// Invoke is called as a result of an application request to run the chaincode.
+func (cc *ERC20Chaincode) Invoke(stub fabric.Stub) fabric.Response {
+	fcn, params := stub.GetFunctionAndParameters()
+
+	return cc.Dispatch(stub, fcn, params)
+}
+
+// Dispatch runs the function of the chaincode, shared by Invoke and the contract API.
+func (cc *ERC20Chaincode) Dispatch(stub fabric.Stub, fcn string, params []string) fabric.Response {
+	// executeSigned is the relayed invoke of a function signed by the acting account
+	if fcn == "executeSigned" {
+		return cc.executeSigned(stub, params)
+	}
+
+	return cc.invoke(stub, fcn, params)
+}
+
+// executeSigned dispatches the function of the signed payload with the signer as the acting account.
+// params - payload json, signature
+func (cc *ERC20Chaincode) executeSigned(stub fabric.Stub, params []string) fabric.Response {
+	fcn, signedParams, signedStub, err := cc.controller.ParseSignedPayload(stub, params)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	return cc.invoke(signedStub, fcn, signedParams)
+}
+
+// invoke dispatches the function to the controller.
+func (cc *ERC20Chaincode) invoke(stub fabric.Stub, fcn string, params []string) fabric.Response {
+	switch fcn {
+	case "totalSupply":
+		return cc.controller.TotalSupply(stub, params)
+	case "balanceOf":
+		return cc.controller.BalanceOf(stub, params)
+	case "transfer":
+		return cc.controller.Transfer(stub, params)
+	case "allowance":
+		return cc.controller.Allowance(stub, params)
+	case "approve":
+		return cc.controller.Approve(stub, params)
+	case "approveWithExpiry":
+		return cc.controller.ApproveWithExpiry(stub, params)
+	case "approvalList":
+		return cc.controller.ApprovalList(stub, params)
+	case "transferFrom":
+		return cc.controller.TransferFrom(stub, params)
+	case "transferFromOther":
+		return cc.controller.TransferFromOther(stub, params)
+	case "transferAndCall":
+		return cc.controller.TransferAndCall(stub, params)
+	case "approveAndCall":
+		return cc.controller.ApproveAndCall(stub, params)
+	case "increaseAllowance":
+		return cc.controller.IncreaseAllowance(stub, params)
+	case "decreaseAllowance":
+		return cc.controller.DecreaseAllowance(stub, params)
+	case "mint":
+		return cc.controller.Mint(stub, params)
+	case "burn":
+		return cc.controller.Burn(stub, params)
+	case "snapshot":
+		return cc.controller.Snapshot(stub, params)
+	case "balanceOfAt":
+		return cc.controller.BalanceOfAt(stub, params)
+	case "totalSupplyAt":
+		return cc.controller.TotalSupplyAt(stub, params)
+	case "delegate":
+		return cc.controller.Delegate(stub, params)
+	case "delegates":
+		return cc.controller.Delegates(stub, params)
+	case "getVotes":
+		return cc.controller.GetVotes(stub, params)
+	case "getPastVotes":
+		return cc.controller.GetPastVotes(stub, params)
+	case "setComplianceOfficer":
+		return cc.controller.SetComplianceOfficer(stub, params)
+	case "setSpendingLimit":
+		return cc.controller.SetSpendingLimit(stub, params)
+	case "remainingLimit":
+		return cc.controller.RemainingLimit(stub, params)
+	case "createMultisig":
+		return cc.controller.CreateMultisig(stub, params)
+	case "proposeTransfer":
+		return cc.controller.ProposeTransfer(stub, params)
+	case "confirm":
+		return cc.controller.Confirm(stub, params)
+	case "revokeConfirmation":
+		return cc.controller.RevokeConfirmation(stub, params)
+	case "getMultisig":
+		return cc.controller.GetMultisig(stub, params)
+	case "pendingTransfers":
+		return cc.controller.PendingTransfers(stub, params)
+	case "setFeeConfig":
+		return cc.controller.SetFeeConfig(stub, params)
+	case "getFeeConfig":
+		return cc.controller.GetFeeConfig(stub, params)
+	case "quoteTransfer":
+		return cc.controller.QuoteTransfer(stub, params)
+	case "privateTransfer":
+		return cc.controller.PrivateTransfer(stub, params)
+	case "privateDeposit":
+		return cc.controller.PrivateDeposit(stub, params)
+	case "privateWithdraw":
+		return cc.controller.PrivateWithdraw(stub, params)
+	case "privateBalanceOf":
+		return cc.controller.PrivateBalanceOf(stub, params)
+	case "registerKey":
+		return cc.controller.RegisterKey(stub, params)
+	case "rotateKey":
+		return cc.controller.RotateKey(stub, params)
+	case "keyOf":
+		return cc.controller.KeyOf(stub, params)
+	case "callerAddress":
+		return cc.controller.CallerAddress(stub, params)
+	case "permit":
+		return cc.controller.Permit(stub, params)
+	case "nonces":
+		return cc.controller.Nonces(stub, params)
+	case "setGovernanceConfig":
+		return cc.controller.SetGovernanceConfig(stub, params)
+	case "propose":
+		return cc.controller.Propose(stub, params)
+	case "castVote":
+		return cc.controller.CastVote(stub, params)
+	case "execute":
+		return cc.controller.Execute(stub, params)
+	case "getProposal":
+		return cc.controller.GetProposal(stub, params)
+	case "proposalList":
+		return cc.controller.ProposalList(stub, params)
+	case "voteList":
+		return cc.controller.VoteList(stub, params)
+	case "paused":
+		return cc.controller.Paused(stub, params)
+	case "upgradeStatus":
+		return cc.controller.UpgradeStatus(stub, params)
+	case "audit":
+		return cc.controller.Audit(stub, params)
+	case "createDistribution":
+		return cc.controller.CreateDistribution(stub, params)
+	case "claimDistribution":
+		return cc.controller.ClaimDistribution(stub, params)
+	case "closeDistribution":
+		return cc.controller.CloseDistribution(stub, params)
+	case "claimableDistribution":
+		return cc.controller.ClaimableDistribution(stub, params)
+	case "getDistribution":
+		return cc.controller.GetDistribution(stub, params)
+	case "createVesting":
+		return cc.controller.CreateVesting(stub, params)
+	case "release":
+		return cc.controller.Release(stub, params)
+	case "revoke":
+		return cc.controller.Revoke(stub, params)
+	case "vestedAmount":
+		return cc.controller.VestedAmount(stub, params)
+	case "releasableAmount":
+		return cc.controller.ReleasableAmount(stub, params)
+	case "getVesting":
+		return cc.controller.GetVesting(stub, params)
+	default:
+		return fabric.Response{Status: 404, Message: "404 Not Found", Payload: nil}
+	}
+}
@@ -1,8 +1,11 @@
+//go:build !fabric2
+// +build !fabric2
+
 /*
  * SPDX-License-Identifier: Apache-2.0
  */
 
-package main
+package chaincode
 
 import (
 	"testing"
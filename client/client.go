@@ -0,0 +1,215 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package client is the typed Go client of the token chaincode.
+// Client builds the args of the functions and parses their payloads, /
+// and Transport sends the transactions to the chaincode: /
+// MockTransport to the chaincode on the in-process mock ledger for the tests, /
+// GatewayTransport to the contract of a Fabric gateway on a real network.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"hypherledgertest2/model"
+)
+
+// Transport sends the transactions to the chaincode, /
+// the failed transactions are returned as the error.
+type Transport interface {
+	// Submit invokes the function, and commits its writes to the ledger
+	Submit(ctx context.Context, fcn string, args []string, transient map[string][]byte) ([]byte, error)
+	// Evaluate invokes the function as a query, without committing it
+	Evaluate(ctx context.Context, fcn string, args []string) ([]byte, error)
+}
+
+// Error is the error response of the chaincode
+type Error struct {
+	Function string
+	Status   int32
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s failed with the status %d, error: %s", e.Function, e.Status, e.Message)
+}
+
+// Client is the client of the token acting for the address, /
+// the address is the caller's address of the functions taking it, e.g. the sender of Transfer.
+type Client struct {
+	transport Transport
+	tokenName string
+	address   string
+}
+
+// New is ...
+func New(transport Transport, tokenName, address string) *Client {
+	return &Client{transport, tokenName, address}
+}
+
+// Address gets the address the client acts for
+func (c *Client) Address() string {
+	return c.address
+}
+
+// TokenName gets the name of the token
+func (c *Client) TokenName() string {
+	return c.tokenName
+}
+
+// CallerAddress gets the address of the identity of the transport
+func (c *Client) CallerAddress(ctx context.Context) (string, error) {
+	payload, err := c.transport.Evaluate(ctx, "callerAddress", []string{})
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+// TotalSupply gets the total supply of the token
+func (c *Client) TotalSupply(ctx context.Context) (int, error) {
+	return c.evaluateInt(ctx, "totalSupply", c.tokenName)
+}
+
+// BalanceOf gets the balance of the address
+func (c *Client) BalanceOf(ctx context.Context, address string) (int, error) {
+	return c.evaluateInt(ctx, "balanceOf", address)
+}
+
+// Transfer moves amount token from the client's address to the recipient
+func (c *Client) Transfer(ctx context.Context, to string, amount int) error {
+	return c.submit(ctx, "transfer", c.address, to, strconv.Itoa(amount))
+}
+
+// TransferFrom moves amount token from the owner to the recipient /
+// using the allowance of the identity of the transport
+func (c *Client) TransferFrom(ctx context.Context, owner, to string, amount int) error {
+	return c.submit(ctx, "transferFrom", owner, to, strconv.Itoa(amount))
+}
+
+// Approve sets amount as the allowance of the spender over the client's tokens
+func (c *Client) Approve(ctx context.Context, spender string, amount int) error {
+	return c.submit(ctx, "approve", c.address, spender, strconv.Itoa(amount))
+}
+
+// ApproveWithExpiry sets amount as the allowance of the spender over the client's tokens until expiresAt
+func (c *Client) ApproveWithExpiry(ctx context.Context, spender string, amount int, expiresAt time.Time) error {
+	return c.submit(ctx, "approveWithExpiry", c.address, spender, strconv.Itoa(amount), strconv.FormatInt(expiresAt.Unix(), 10))
+}
+
+// IncreaseAllowance increases the allowance of the spender over the client's tokens
+func (c *Client) IncreaseAllowance(ctx context.Context, spender string, amount int) error {
+	return c.submit(ctx, "increaseAllowance", c.address, spender, strconv.Itoa(amount))
+}
+
+// DecreaseAllowance decreases the allowance of the spender over the client's tokens
+func (c *Client) DecreaseAllowance(ctx context.Context, spender string, amount int) error {
+	return c.submit(ctx, "decreaseAllowance", c.address, spender, strconv.Itoa(amount))
+}
+
+// Allowance gets the allowance of the spender over the owner's tokens, zero if it is expired
+func (c *Client) Allowance(ctx context.Context, owner, spender string) (int, error) {
+	return c.evaluateInt(ctx, "allowance", owner, spender)
+}
+
+// ApprovalList gets the allowances of the spenders over the owner's tokens
+func (c *Client) ApprovalList(ctx context.Context, owner string) ([]model.ApprovalEvent, error) {
+	approvals := []model.ApprovalEvent{}
+	err := c.evaluateJSON(ctx, &approvals, "approvalList", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return approvals, nil
+}
+
+// Mint creates amount token to the recipient, the identity of the transport must be the owner of the token
+func (c *Client) Mint(ctx context.Context, to string, amount int) error {
+	return c.submit(ctx, "mint", c.tokenName, to, strconv.Itoa(amount))
+}
+
+// Burn destroys amount token of the identity of the transport
+func (c *Client) Burn(ctx context.Context, amount int) error {
+	return c.submit(ctx, "burn", c.tokenName, strconv.Itoa(amount))
+}
+
+// Audit gets the page of the audit of the balances against the total supply, /
+// the empty bookmark starts the audit and the report has the bookmark of the next page
+func (c *Client) Audit(ctx context.Context, pageSize int, bookmark string) (*model.AuditReport, error) {
+	report := &model.AuditReport{}
+	err := c.evaluateJSON(ctx, report, "audit", c.tokenName, strconv.Itoa(pageSize), bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// PrivateDeposit moves amount token from the public balance of the transport identity to its private balance, /
+// the amount and the salt are sent as the transient data. Returns the salted hash on the public ledger.
+func (c *Client) PrivateDeposit(ctx context.Context, amount int, salt string) (string, error) {
+	return c.submitPrivate(ctx, "privateDeposit", amount, salt)
+}
+
+// PrivateTransfer moves amount token from the private balance of the transport identity to the recipient's, /
+// the amount and the salt are sent as the transient data. Returns the salted hash on the public ledger.
+func (c *Client) PrivateTransfer(ctx context.Context, to string, amount int, salt string) (string, error) {
+	return c.submitPrivate(ctx, "privateTransfer", amount, salt, to)
+}
+
+// PrivateBalanceOf gets the private balance of the address
+func (c *Client) PrivateBalanceOf(ctx context.Context, address string) (int, error) {
+	return c.evaluateInt(ctx, "privateBalanceOf", address)
+}
+
+func (c *Client) submit(ctx context.Context, fcn string, args ...string) error {
+	_, err := c.transport.Submit(ctx, fcn, args, nil)
+
+	return err
+}
+
+func (c *Client) submitPrivate(ctx context.Context, fcn string, amount int, salt string, args ...string) (string, error) {
+	transient := map[string][]byte{"amount": []byte(strconv.Itoa(amount)), "salt": []byte(salt)}
+	payload, err := c.transport.Submit(ctx, fcn, args, transient)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+// evaluateInt evaluates the function returning an integer
+func (c *Client) evaluateInt(ctx context.Context, fcn string, args ...string) (int, error) {
+	payload, err := c.transport.Evaluate(ctx, fcn, args)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("%s returned a non-integer payload %q", fcn, payload)
+	}
+
+	return value, nil
+}
+
+// evaluateJSON evaluates the function returning a json
+func (c *Client) evaluateJSON(ctx context.Context, value interface{}, fcn string, args ...string) error {
+	payload, err := c.transport.Evaluate(ctx, fcn, args)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(payload, value)
+	if err != nil {
+		return fmt.Errorf("%s returned an invalid json, error: %s", fcn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+//go:build !fabric2
+// +build !fabric2
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hypherledgertest2/mockledger"
+)
+
+// newTokenClients deploys the token owned by the address of the identity "owner"
+func newTokenClients(t *testing.T) (*mockledger.Ledger, *Client) {
+	ledger := mockledger.New()
+	ownerAddress, err := ledger.Address("owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ledger.Init(mockledger.Transaction{Function: "init", Args: []string{"token", "TKN", ownerAddress, "1000"}})
+	if err != nil || !result.OK() {
+		t.Fatal("Init failed", err, result)
+	}
+
+	return ledger, New(NewMockTransport(ledger, "owner"), "token", ownerAddress)
+}
+
+func TestClientTransfersAndAllowances(t *testing.T) {
+	ctx := context.Background()
+	ledger, owner := newTokenClients(t)
+
+	// alice is the identity of the ledger spending the allowance
+	aliceTransport := NewMockTransport(ledger, "alice")
+	aliceAddress, err := New(aliceTransport, "token", "").CallerAddress(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := New(aliceTransport, "token", aliceAddress)
+
+	if err := owner.Transfer(ctx, "bob", 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.Approve(ctx, alice.Address(), 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.IncreaseAllowance(ctx, alice.Address(), 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.TransferFrom(ctx, owner.Address(), "carol", 120); err != nil {
+		t.Fatal(err)
+	}
+
+	for address, expected := range map[string]int{owner.Address(): 580, "bob": 300, "carol": 120} {
+		balance, err := owner.BalanceOf(ctx, address)
+		if err != nil || balance != expected {
+			t.Fatalf("balance of %s: expected %d, got %d %v", address, expected, balance, err)
+		}
+	}
+	if allowance, err := owner.Allowance(ctx, owner.Address(), alice.Address()); err != nil || allowance != 30 {
+		t.Fatalf("expected the allowance 30, got %d %v", allowance, err)
+	}
+
+	approvals, err := owner.ApprovalList(ctx, owner.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || approvals[0].Spender != alice.Address() || approvals[0].Amount != 30 {
+		t.Fatalf("expected the allowance 30 of alice, got %+v", approvals)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := owner.ApproveWithExpiry(ctx, "bob", 10, expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	approvals, _ = owner.ApprovalList(ctx, owner.Address())
+	if len(approvals) != 2 {
+		t.Fatalf("expected two allowances, got %+v", approvals)
+	}
+	for _, approval := range approvals {
+		if approval.Spender == "bob" && approval.ExpiresAt != expiresAt.Unix() {
+			t.Fatalf("expected the expiry %d, got %+v", expiresAt.Unix(), approval)
+		}
+	}
+}
+
+func TestClientSupplyAndAudit(t *testing.T) {
+	ctx := context.Background()
+	_, owner := newTokenClients(t)
+
+	if err := owner.Mint(ctx, "bob", 500); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.Burn(ctx, 200); err != nil {
+		t.Fatal(err)
+	}
+	if supply, err := owner.TotalSupply(ctx); err != nil || supply != 1300 {
+		t.Fatalf("expected the total supply 1300, got %d %v", supply, err)
+	}
+
+	report, err := owner.Audit(ctx, 100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Complete || !report.Consistent || report.BalanceSum != 1300 {
+		t.Fatalf("expected the consistent ledger, got %+v", report)
+	}
+}
+
+func TestClientPrivateBalances(t *testing.T) {
+	ctx := context.Background()
+	_, owner := newTokenClients(t)
+
+	if hash, err := owner.PrivateDeposit(ctx, 100, "salt"); err != nil || len(hash) == 0 {
+		t.Fatalf("expected the hash of the deposit, got %q %v", hash, err)
+	}
+	if _, err := owner.PrivateTransfer(ctx, "bob", 40, "salt2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for address, expected := range map[string]int{owner.Address(): 60, "bob": 40} {
+		balance, err := owner.PrivateBalanceOf(ctx, address)
+		if err != nil || balance != expected {
+			t.Fatalf("private balance of %s: expected %d, got %d %v", address, expected, balance, err)
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	_, owner := newTokenClients(t)
+
+	err := owner.Transfer(context.Background(), "bob", 5000)
+	chaincodeErr, ok := err.(*Error)
+	if !ok || chaincodeErr.Function != "transfer" || chaincodeErr.Status != 500 || len(chaincodeErr.Message) == 0 {
+		t.Fatalf("expected the chaincode error of transfer, got %v", err)
+	}
+	if balance, _ := owner.BalanceOf(context.Background(), owner.Address()); balance != 1000 {
+		t.Fatalf("the failed transfer must not change the balance, got %d", balance)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := owner.Transfer(ctx, "bob", 10); err != context.Canceled {
+		t.Fatalf("expected the canceled context, got %v", err)
+	}
+}
+
+// fakeContract is the Contract of a gateway recording the transactions
+type fakeContract struct {
+	calls   []string
+	payload []byte
+	err     error
+}
+
+func (c *fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	c.calls = append(c.calls, "submit "+name+" "+strings.Join(args, ","))
+	return c.payload, c.err
+}
+
+func (c *fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	c.calls = append(c.calls, "evaluate "+name+" "+strings.Join(args, ","))
+	return c.payload, c.err
+}
+
+// fakeTransientContract is the fakeContract taking the transient data
+type fakeTransientContract struct {
+	fakeContract
+	transient map[string][]byte
+}
+
+func (c *fakeTransientContract) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	c.transient = transient
+	return c.SubmitTransaction(name, args...)
+}
+
+func TestGatewayTransport(t *testing.T) {
+	ctx := context.Background()
+	contract := &fakeContract{payload: []byte("42")}
+	c := New(NewGatewayTransport(contract), "token", "owner")
+
+	if balance, err := c.BalanceOf(ctx, "bob"); err != nil || balance != 42 {
+		t.Fatalf("expected the balance 42, got %d %v", balance, err)
+	}
+	if err := c.Transfer(ctx, "bob", 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PrivateDeposit(ctx, 10, "salt"); err != ErrTransientNotSupported {
+		t.Fatalf("expected ErrTransientNotSupported, got %v", err)
+	}
+
+	expected := []string{"evaluate balanceOf bob", "submit transfer owner,bob,10"}
+	if strings.Join(contract.calls, ";") != strings.Join(expected, ";") {
+		t.Fatalf("expected the calls %v, got %v", expected, contract.calls)
+	}
+
+	// the errors of the gateway are returned as they are
+	contract.err = errors.New("endorsement failed")
+	if err := c.Transfer(ctx, "bob", 10); err != contract.err {
+		t.Fatalf("expected the error of the gateway, got %v", err)
+	}
+
+	transientContract := &fakeTransientContract{fakeContract: fakeContract{payload: []byte("hash")}}
+	c = New(NewGatewayTransport(transientContract), "token", "owner")
+	if hash, err := c.PrivateDeposit(ctx, 10, "salt"); err != nil || hash != "hash" {
+		t.Fatalf("expected the hash, got %q %v", hash, err)
+	}
+	if string(transientContract.transient["amount"]) != "10" || string(transientContract.transient["salt"]) != "salt" {
+		t.Fatalf("expected the transient amount and salt, got %v", transientContract.transient)
+	}
+}
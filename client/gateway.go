@@ -0,0 +1,69 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// Contract is the contract of a Fabric gateway connected to the channel of the chaincode. /
+// The Contract of fabric-sdk-go's gateway package and of fabric-gateway's client package implement it.
+type Contract interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// TransientContract is the contract submitting the transactions with the transient data, /
+// the SDKs take the transient data by their own options so it is implemented by an adapter of the SDK, e.g.
+//
+//	func (c adapter) SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+//		return c.Submit(name, client.WithArguments(args...), client.WithTransient(transient))
+//	}
+type TransientContract interface {
+	Contract
+	SubmitTransient(name string, transient map[string][]byte, args ...string) ([]byte, error)
+}
+
+// ErrTransientNotSupported is returned when the transient data is submitted to a contract without TransientContract
+var ErrTransientNotSupported = errors.New("the contract does not support the transient data")
+
+// GatewayTransport sends the transactions to the contract of a Fabric gateway, /
+// the endorsement and the commit are done by the gateway as the identity of its wallet.
+type GatewayTransport struct {
+	contract Contract
+}
+
+// NewGatewayTransport is ...
+func NewGatewayTransport(contract Contract) *GatewayTransport {
+	return &GatewayTransport{contract}
+}
+
+// Submit submits the transaction, and waits for the commit
+func (t *GatewayTransport) Submit(ctx context.Context, fcn string, args []string, transient map[string][]byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(transient) == 0 {
+		return t.contract.SubmitTransaction(fcn, args...)
+	}
+
+	transientContract, ok := t.contract.(TransientContract)
+	if !ok {
+		return nil, ErrTransientNotSupported
+	}
+
+	return transientContract.SubmitTransient(fcn, transient, args...)
+}
+
+// Evaluate evaluates the transaction on a peer
+func (t *GatewayTransport) Evaluate(ctx context.Context, fcn string, args []string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return t.contract.EvaluateTransaction(fcn, args...)
+}
@@ -0,0 +1,53 @@
+//go:build !fabric2
+// +build !fabric2
+
+package client
+
+import (
+	"context"
+
+	"hypherledgertest2/mockledger"
+)
+
+// MockTransport sends the transactions to the chaincode on the in-process mock ledger /
+// as the named identity of the ledger
+type MockTransport struct {
+	ledger   *mockledger.Ledger
+	identity string
+}
+
+// NewMockTransport is ...
+func NewMockTransport(ledger *mockledger.Ledger, identity string) *MockTransport {
+	return &MockTransport{ledger, identity}
+}
+
+// Submit invokes the function, the failed transaction is rolled back by the ledger
+func (t *MockTransport) Submit(ctx context.Context, fcn string, args []string, transient map[string][]byte) ([]byte, error) {
+	return t.invoke(ctx, fcn, args, transient)
+}
+
+// Evaluate invokes the function, the mock ledger does not tell the queries from the transactions
+func (t *MockTransport) Evaluate(ctx context.Context, fcn string, args []string) ([]byte, error) {
+	return t.invoke(ctx, fcn, args, nil)
+}
+
+func (t *MockTransport) invoke(ctx context.Context, fcn string, args []string, transient map[string][]byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := t.ledger.Invoke(mockledger.Transaction{
+		Identity:  t.identity,
+		Function:  fcn,
+		Args:      args,
+		Transient: transient,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.OK() {
+		return nil, &Error{fcn, result.Status, result.Message}
+	}
+
+	return result.Payload, nil
+}
@@ -0,0 +1,147 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Command erc20cli drives the token chaincode on the in-process mock ledger, without a Fabric network.
+//
+//	erc20cli -state ledger.json init token TKN owner 1000
+//	erc20cli -state ledger.json -as alice callerAddress
+//	erc20cli -state ledger.json transfer owner 1f0c...d2 100
+//	erc20cli -state ledger.json -as alice -transient amount=100 -transient salt=abc privateDeposit
+//	erc20cli -state ledger.json identities
+//
+// The functions and their args are the same as the Invoke of the chaincode, init runs Init.
+// The result is printed as json with the payload and the emitted events.
+// Without -state the ledger lives for a single command.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hypherledgertest2/mockledger"
+)
+
+// transientFlag is the repeated -transient key=value flag
+type transientFlag map[string][]byte
+
+func (f transientFlag) String() string {
+	return fmt.Sprint(map[string][]byte(f))
+}
+
+func (f transientFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return errors.New("transient must be key=value")
+	}
+	f[value[:i]] = []byte(value[i+1:])
+
+	return nil
+}
+
+// identityAddress is the output of the identities command
+type identityAddress struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func main() {
+	// the chaincode logs to stdout, keep stdout for the json output
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+
+	os.Exit(run(os.Args[1:], stdout, os.Stderr))
+}
+
+// run runs the command and returns the exit code, /
+// 1 if the transaction failed and 2 if the command is invalid
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("erc20cli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	statePath := flags.String("state", "", "file of the ledger kept between the commands")
+	identity := flags.String("as", "", "name of the creator identity, created on the first use")
+	at := flags.String("at", "", "transaction time, RFC3339 or unix seconds (default now)")
+	transient := transientFlag{}
+	flags.Var(transient, "transient", "transient data key=value, repeatable")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: erc20cli [flags] init|identities|<function> [args...]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	txTime, err := mockledger.ParseTime(*at)
+	if err != nil {
+		fmt.Fprintln(stderr, "-at", err)
+		return 2
+	}
+
+	ledger, err := mockledger.LoadFile(*statePath)
+	if err != nil {
+		fmt.Fprintln(stderr, "failed to load the ledger:", err)
+		return 2
+	}
+
+	fcn, fcnArgs := flags.Arg(0), flags.Args()[1:]
+	var output interface{}
+	exitCode := 0
+
+	switch fcn {
+	case "identities":
+		identities := []identityAddress{}
+		for _, name := range ledger.Identities() {
+			address, err := ledger.Address(name)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 1
+			}
+			identities = append(identities, identityAddress{name, address})
+		}
+		output = identities
+	default:
+		tx := mockledger.Transaction{Identity: *identity, Function: fcn, Args: fcnArgs, Transient: transient, Time: txTime}
+
+		var result *mockledger.Result
+		if fcn == "init" {
+			result, err = ledger.Init(tx)
+		} else {
+			result, err = ledger.Invoke(tx)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if !result.OK() {
+			exitCode = 1
+		}
+		output = result
+	}
+
+	if err := ledger.SaveFile(*statePath); err != nil {
+		fmt.Fprintln(stderr, "failed to save the ledger:", err)
+		return 1
+	}
+
+	outputBytes, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(outputBytes))
+
+	return exitCode
+}
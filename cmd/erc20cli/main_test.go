@@ -0,0 +1,159 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cliResult is the json printed for a transaction
+type cliResult struct {
+	Status  int32           `json:"status"`
+	Message string          `json:"message"`
+	Payload json.RawMessage `json:"payload"`
+	Events  []struct {
+		Name    string          `json:"name"`
+		Payload json.RawMessage `json:"payload"`
+	} `json:"events"`
+}
+
+func runCLI(t *testing.T, expectedCode int, args ...string) []byte {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := run(args, stdout, stderr); code != expectedCode {
+		t.Fatalf("erc20cli %v: expected the exit code %d, got %d: %s %s", args, expectedCode, code, stdout, stderr)
+	}
+
+	return stdout.Bytes()
+}
+
+func runTx(t *testing.T, expectedCode int, args ...string) cliResult {
+	result := cliResult{}
+	if err := json.Unmarshal(runCLI(t, expectedCode, args...), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestCLIKeepsTheLedgerInTheStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erc20cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "ledger.json")
+
+	runTx(t, 0, "-state", state, "init", "token", "TKN", "owner", "1000")
+
+	address := ""
+	json.Unmarshal(runTx(t, 0, "-state", state, "-as", "alice", "callerAddress").Payload, &address)
+
+	transfer := runTx(t, 0, "-state", state, "transfer", "owner", address, "300")
+	if len(transfer.Events) != 1 || transfer.Events[0].Name != "transferEvent" {
+		t.Fatalf("expected the transfer event, got %+v", transfer.Events)
+	}
+	event := map[string]string{}
+	if err := json.Unmarshal(transfer.Events[0].Payload, &event); err != nil || event["transferedMoney"] != "300" {
+		t.Fatalf("expected the event payload as json, got %s", transfer.Events[0].Payload)
+	}
+
+	failed := runTx(t, 1, "-state", state, "-as", "alice", "transfer", address, "owner", "301")
+	if failed.Status < 400 || failed.Message == "" {
+		t.Fatalf("expected the failed transfer, got %+v", failed)
+	}
+
+	balance := runTx(t, 0, "-state", state, "balanceOf", address)
+	if string(balance.Payload) != `"300"` {
+		t.Fatalf("expected the balance 300, got %s", balance.Payload)
+	}
+
+	identities := []identityAddress{}
+	json.Unmarshal(runCLI(t, 0, "-state", state, "identities"), &identities)
+	if len(identities) != 1 || identities[0].Name != "alice" || identities[0].Address != address {
+		t.Fatalf("expected alice of %s, got %+v", address, identities)
+	}
+}
+
+func TestCLITransientAndTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erc20cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "ledger.json")
+
+	runTx(t, 0, "-state", state, "init", "token", "TKN", "owner", "1000")
+
+	// alice deposits her public balance
+	address := ""
+	json.Unmarshal(runTx(t, 0, "-state", state, "-as", "alice", "callerAddress").Payload, &address)
+	runTx(t, 0, "-state", state, "transfer", "owner", address, "100")
+	runTx(t, 0, "-state", state, "-as", "alice", "-transient", "amount=100", "-transient", "salt=abc", "privateDeposit")
+
+	private := runTx(t, 0, "-state", state, "privateBalanceOf", address)
+	if string(private.Payload) != `"100"` {
+		t.Fatalf("expected the private balance 100, got %s", private.Payload)
+	}
+
+	// the allowance expires at 2030-01-01
+	runTx(t, 0, "-state", state, "-at", "2029-12-31T00:00:00Z", "approveWithExpiry", "owner", "spender", "50", "1893456000")
+	expired := runTx(t, 0, "-state", state, "-at", "1893456000", "allowance", "owner", "spender")
+	if string(expired.Payload) != `"0"` {
+		t.Fatalf("expected the expired allowance, got %s", expired.Payload)
+	}
+}
+
+func TestCLIUsage(t *testing.T) {
+	runCLI(t, 2)
+	runCLI(t, 2, "-at", "yesterday", "totalSupply", "token")
+	runCLI(t, 2, "-transient", "amount", "privateDeposit")
+}
+
+func TestCLIInitWithInvalidAmount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erc20cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "ledger.json")
+
+	// the invalid amount fails the transaction instead of exiting the process
+	for _, amount := range []string{"abc", "-5"} {
+		failed := runTx(t, 1, "-state", state, "init", "token", "TKN", "owner", amount)
+		if failed.Status < 400 || !strings.Contains(failed.Message, "amount") {
+			t.Fatalf("init with the amount %s: expected the failed transaction, got %+v", amount, failed)
+		}
+	}
+
+	runTx(t, 0, "-state", state, "init", "token", "TKN", "owner", "1000")
+}
+
+func TestCLIFailsTheTransactionOfTheUnexpectedError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erc20cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "ledger.json")
+
+	runTx(t, 0, "-state", state, "init", "token", "TKN", "owner", "1000")
+
+	// the composite key of the invalid utf-8 address cannot be made
+	result := runTx(t, 1, "-state", state, "delegates", "a\xff")
+	if !strings.Contains(result.Message, "failed to make a composite key") {
+		t.Fatalf("expected the failed transaction, got %+v", result)
+	}
+
+	runTx(t, 0, "-state", state, "delegates", "owner")
+}
@@ -0,0 +1,77 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Command erc20gateway serves the REST API of the token chaincode on the in-process mock ledger.
+//
+//	erc20gateway -addr 127.0.0.1:8080 -state ledger.json
+//	curl -X POST localhost:8080/init -d '{"tokenName":"token","symbol":"TKN","owner":"owner","amount":1000}'
+//	curl -X POST localhost:8080/transfers -d '{"from":"owner","to":"bob","amount":10}'
+//	curl localhost:8080/balances/bob
+//	curl -N localhost:8080/events
+//
+// The ledger is loaded from the -state file, and saved to it after each committed transaction and on the shutdown.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"hypherledgertest2/gateway"
+	"hypherledgertest2/mockledger"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address of the HTTP server")
+	statePath := flag.String("state", "", "file of the ledger kept between the runs")
+	flag.Parse()
+
+	ledger, err := mockledger.LoadFile(*statePath)
+	if err != nil {
+		log.Fatalln("failed to load the ledger:", err)
+	}
+
+	g := gateway.NewWithState(ledger, *statePath)
+	server := &http.Server{Addr: *addr, Handler: g}
+
+	// the shutdown waits for the active requests, so the event streams are ended when it starts
+	server.RegisterOnShutdown(g.Close)
+
+	// shut down on the signal
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	shutdown := make(chan error, 1)
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- server.Shutdown(ctx)
+	}()
+
+	log.Println("erc20gateway is listening on", *addr)
+	err = server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		log.Fatalln(err)
+	}
+
+	// ListenAndServe returns as soon as the shutdown starts, save after the transactions are done
+	err = <-shutdown
+	if err != nil {
+		log.Println("failed to shut down the server gracefully:", err)
+		server.Close()
+	}
+
+	err = ledger.SaveFile(*statePath)
+	if err != nil {
+		log.Fatalln("failed to save the ledger:", err)
+	}
+}
@@ -0,0 +1,222 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"hypherledgertest2/controller"
+	"hypherledgertest2/mockledger"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// erc20Fixture is the token deployed to the mock ledger with the owner's balance, /
+// invoked by the named identities of the ledger, no creator if the name is empty
+type erc20Fixture struct {
+	t      *testing.T
+	ledger *mockledger.Ledger
+}
+
+func newERC20Fixture(t *testing.T, owner string, supply int) *erc20Fixture {
+	f := &erc20Fixture{t: t, ledger: mockledger.New()}
+	f.init(owner, supply)
+
+	return f
+}
+
+// newOwnedFixture deploys the token owned by the address of the identity "owner"
+func newOwnedFixture(t *testing.T, supply int) (*erc20Fixture, string) {
+	f := &erc20Fixture{t: t, ledger: mockledger.New()}
+	f.init(f.address("owner"), supply)
+
+	return f, "owner"
+}
+
+func (f *erc20Fixture) init(owner string, supply int) {
+	res, err := f.ledger.Init(mockledger.Transaction{Function: "init", Args: []string{"token", "TKN", owner, strconv.Itoa(supply)}})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if !res.OK() {
+		f.t.Fatal("Init failed", res.Message)
+	}
+}
+
+// invokeAt invokes the function with the identity as the caller at the given time
+func (f *erc20Fixture) invokeAt(identity string, txTime time.Time, fcn string, params ...string) *mockledger.Result {
+	res, err := f.ledger.Invoke(mockledger.Transaction{Identity: identity, Function: fcn, Args: params, Time: txTime})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	return res
+}
+
+func (f *erc20Fixture) invoke(identity string, fcn string, params ...string) *mockledger.Result {
+	return f.invokeAt(identity, time.Now(), fcn, params...)
+}
+
+func (f *erc20Fixture) mustInvoke(identity string, fcn string, params ...string) []byte {
+	res := f.invoke(identity, fcn, params...)
+	if res.Status != shim.OK {
+		f.t.Fatalf("%s%v failed: %s", fcn, params, res.Message)
+	}
+
+	return res.Payload
+}
+
+func (f *erc20Fixture) mustFail(identity string, fcn string, params ...string) {
+	res := f.invoke(identity, fcn, params...)
+	if res.Status == shim.OK {
+		f.t.Fatalf("%s%v must fail", fcn, params)
+	}
+}
+
+func (f *erc20Fixture) address(identity string) string {
+	return string(f.mustInvoke(identity, "callerAddress"))
+}
+
+func (f *erc20Fixture) expectBalance(address string, expected int) {
+	balance := string(f.mustInvoke("", "balanceOf", address))
+	if balance != strconv.Itoa(expected) {
+		f.t.Fatalf("balance of %s: expected %d, got %s", address, expected, balance)
+	}
+}
+
+func (f *erc20Fixture) expectAllowance(owner, spender string, expected int) {
+	allowance := string(f.mustInvoke("", "allowance", owner, spender))
+	if allowance != strconv.Itoa(expected) {
+		f.t.Fatalf("allowance of %s to %s: expected %d, got %s", owner, spender, expected, allowance)
+	}
+}
+
+func TestTransferFromDecreasesOnlySpendersAllowance(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender, other := "spender", "other"
+	spenderAddress, otherAddress := f.address(spender), f.address(other)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, "300")
+	f.mustInvoke("", "approve", "owner", otherAddress, "200")
+
+	f.mustInvoke(spender, "transferFrom", "owner", "recipient", "100")
+
+	f.expectBalance("owner", 900)
+	f.expectBalance("recipient", 100)
+	f.expectAllowance("owner", spenderAddress, 200)
+	f.expectAllowance("owner", otherAddress, 200)
+	f.expectAllowance("owner", "recipient", 0)
+}
+
+func TestTransferFromOverAllowanceFails(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender := "spender"
+	spenderAddress := f.address(spender)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, "100")
+	f.mustFail(spender, "transferFrom", "owner", "recipient", "101")
+
+	f.expectBalance("owner", 1000)
+	f.expectAllowance("owner", spenderAddress, 100)
+}
+
+func TestTransferFromExactAllowance(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender := "spender"
+	spenderAddress := f.address(spender)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, "100")
+	f.mustInvoke(spender, "transferFrom", "owner", "recipient", "100")
+
+	f.expectBalance("owner", 900)
+	f.expectAllowance("owner", spenderAddress, 0)
+	f.mustFail(spender, "transferFrom", "owner", "recipient", "1")
+}
+
+func TestTransferFromInfiniteAllowance(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender := "spender"
+	spenderAddress := f.address(spender)
+	infinite := strconv.Itoa(controller.InfiniteAllowance)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, infinite)
+	f.mustInvoke(spender, "transferFrom", "owner", "recipient", "400")
+	f.mustInvoke(spender, "transferFrom", "owner", "recipient", "600")
+
+	f.expectBalance("owner", 0)
+	f.expectBalance("recipient", 1000)
+	f.expectAllowance("owner", spenderAddress, controller.InfiniteAllowance)
+}
+
+func TestTransferFromInsufficientBalance(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 100)
+	spender := "spender"
+	spenderAddress := f.address(spender)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, "500")
+	f.mustFail(spender, "transferFrom", "owner", "recipient", "200")
+
+	f.expectBalance("owner", 100)
+	f.expectAllowance("owner", spenderAddress, 500)
+}
+
+func TestTransferFromWithoutApproval(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender, stranger := "spender", "stranger"
+	spenderAddress := f.address(spender)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, "500")
+
+	// the spender cannot be passed as a param, only the caller's allowance is spent
+	f.mustFail(stranger, "transferFrom", "owner", "recipient", "100")
+	f.mustFail(stranger, "transferFrom", "owner", spenderAddress, "recipient", "100")
+
+	f.expectBalance("owner", 1000)
+	f.expectAllowance("owner", spenderAddress, 500)
+}
+
+func TestTransferFromExpiredAllowance(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender := "spender"
+	spenderAddress := f.address(spender)
+	expiresAt := time.Now().Add(time.Hour)
+
+	f.mustInvoke("", "approveWithExpiry", "owner", spenderAddress, "500", strconv.FormatInt(expiresAt.Unix(), 10))
+	f.mustInvoke(spender, "transferFrom", "owner", "recipient", "100")
+	f.expectAllowance("owner", spenderAddress, 400)
+
+	res := f.invokeAt(spender, expiresAt.Add(time.Second), "transferFrom", "owner", "recipient", "100")
+	if res.Status == shim.OK {
+		t.Fatal("transferFrom with an expired allowance must fail")
+	}
+
+	f.expectBalance("owner", 900)
+}
+
+func TestApproveZeroResetsAllowance(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	spender := "spender"
+	spenderAddress := f.address(spender)
+
+	f.mustInvoke("", "approve", "owner", spenderAddress, "500")
+	f.mustInvoke("", "approve", "owner", spenderAddress, "0")
+
+	f.expectAllowance("owner", spenderAddress, 0)
+	f.mustFail(spender, "transferFrom", "owner", "recipient", "1")
+	f.mustFail("", "approve", "owner", spenderAddress, "-1")
+}
+
+func TestTransferFromWithoutIdentity(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+
+	f.mustFail("", "transferFrom", "owner", "recipient", "100")
+
+	f.expectBalance("owner", 1000)
+}
@@ -0,0 +1,153 @@
+//go:build fabric2
+// +build fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"hypherledgertest2/chaincode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ERC20Contract is the contract API implementation of the chaincode for Fabric 2.x.
+// It exposes the same functions as chaincode.ERC20Chaincode.Invoke, /
+// so the clients invoke the functions by the same names and params on both versions.
+// The functions of the ERC20 standard are also typed transactions described in the contract metadata, /
+// the other functions take positional string params shared with Invoke and are dispatched by the name.
+type ERC20Contract struct {
+	contractapi.Contract
+	chaincode *chaincode.ERC20Chaincode
+}
+
+// NewContract is ...
+func NewContract() *ERC20Contract {
+	contract := &ERC20Contract{chaincode: chaincode.NewChaincode()}
+
+	// the functions are not the methods of the contract, /
+	// they are dispatched by the name like ERC20Chaincode.Invoke
+	contract.UnknownTransaction = contract.dispatch
+
+	return contract
+}
+
+// Init initializes the token, or keeps the state and migrates it on the upgrade.
+// params : tokenName, symbol, owner(address), amount
+func (c *ERC20Contract) Init(ctx contractapi.TransactionContextInterface, tokenName, symbol, owner, amount string) error {
+	response := c.chaincode.InitToken(ctx.GetStub(), []string{tokenName, symbol, owner, amount})
+	if response.GetStatus() >= 400 {
+		return errors.New(response.GetMessage())
+	}
+
+	return nil
+}
+
+// TotalSupply gets the amount of token in the ledger.
+func (c *ERC20Contract) TotalSupply(ctx contractapi.TransactionContextInterface, tokenName string) (uint64, error) {
+	payload, err := c.invoke(ctx, "totalSupply", tokenName)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(payload, 10, 64)
+}
+
+// BalanceOf gets the amount of token owned by the address.
+func (c *ERC20Contract) BalanceOf(ctx contractapi.TransactionContextInterface, address string) (int, error) {
+	payload, err := c.invoke(ctx, "balanceOf", address)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(payload)
+}
+
+// Allowance gets the amount the spender can still move from the owner tokens.
+func (c *ERC20Contract) Allowance(ctx contractapi.TransactionContextInterface, owner, spender string) (int, error) {
+	payload, err := c.invoke(ctx, "allowance", owner, spender)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(payload)
+}
+
+// Transfer moves amount token from the sender to the recipient.
+func (c *ERC20Contract) Transfer(ctx contractapi.TransactionContextInterface, sender, recipient string, amount int) error {
+	_, err := c.invoke(ctx, "transfer", sender, recipient, strconv.Itoa(amount))
+
+	return err
+}
+
+// Approve sets amount as the allowance of the spender over the owner tokens.
+func (c *ERC20Contract) Approve(ctx contractapi.TransactionContextInterface, owner, spender string, amount int) error {
+	_, err := c.invoke(ctx, "approve", owner, spender, strconv.Itoa(amount))
+
+	return err
+}
+
+// TransferFrom moves amount token from the owner to the recipient with the allowance of the caller.
+func (c *ERC20Contract) TransferFrom(ctx contractapi.TransactionContextInterface, owner, recipient string, amount int) error {
+	_, err := c.invoke(ctx, "transferFrom", owner, recipient, strconv.Itoa(amount))
+
+	return err
+}
+
+// IncreaseAllowance increases the allowance of the spender over the owner tokens.
+func (c *ERC20Contract) IncreaseAllowance(ctx contractapi.TransactionContextInterface, owner, spender string, amount int) error {
+	_, err := c.invoke(ctx, "increaseAllowance", owner, spender, strconv.Itoa(amount))
+
+	return err
+}
+
+// DecreaseAllowance decreases the allowance of the spender over the owner tokens.
+func (c *ERC20Contract) DecreaseAllowance(ctx contractapi.TransactionContextInterface, owner, spender string, amount int) error {
+	_, err := c.invoke(ctx, "decreaseAllowance", owner, spender, strconv.Itoa(amount))
+
+	return err
+}
+
+// Mint creates amount token to the recipient, the caller must be the owner of the token.
+func (c *ERC20Contract) Mint(ctx contractapi.TransactionContextInterface, tokenName, recipient string, amount int) error {
+	_, err := c.invoke(ctx, "mint", tokenName, recipient, strconv.Itoa(amount))
+
+	return err
+}
+
+// Burn destroys amount token of the caller.
+func (c *ERC20Contract) Burn(ctx contractapi.TransactionContextInterface, tokenName string, amount int) error {
+	_, err := c.invoke(ctx, "burn", tokenName, strconv.Itoa(amount))
+
+	return err
+}
+
+// dispatch runs the function of the transaction that is not typed with the shared controller.
+// Returns the payload of the function as string.
+func (c *ERC20Contract) dispatch(ctx contractapi.TransactionContextInterface) (string, error) {
+	fcn, params := ctx.GetStub().GetFunctionAndParameters()
+
+	// the function can be qualified by the contract name
+	if i := strings.LastIndex(fcn, ":"); i >= 0 {
+		fcn = fcn[i+1:]
+	}
+
+	return c.invoke(ctx, fcn, params...)
+}
+
+// invoke runs the function by the name with the shared controller.
+// Returns the payload of the function as string.
+func (c *ERC20Contract) invoke(ctx contractapi.TransactionContextInterface, fcn string, params ...string) (string, error) {
+	response := c.chaincode.Dispatch(ctx.GetStub(), fcn, params)
+	if response.GetStatus() >= 400 {
+		return "", errors.New(response.GetMessage())
+	}
+
+	return string(response.GetPayload()), nil
+}
@@ -0,0 +1,87 @@
+//go:build fabric2
+// +build fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// newContractStub deploys the contract API chaincode with the owner's balance
+func newContractStub(t *testing.T) *shimtest.MockStub {
+	contractChaincode, err := contractapi.NewChaincode(NewContract())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := shimtest.NewMockStub("erc20", contractChaincode)
+	res := stub.MockInit("init", [][]byte{[]byte("Init"), []byte("token"), []byte("TKN"), []byte("owner"), []byte("1000")})
+	if res.Status != 200 {
+		t.Fatal("Init failed", res.Message)
+	}
+
+	return stub
+}
+
+func invokeContract(t *testing.T, stub *shimtest.MockStub, fcn string, params ...string) string {
+	args := [][]byte{[]byte(fcn)}
+	for _, param := range params {
+		args = append(args, []byte(param))
+	}
+
+	res := stub.MockInvoke("tx", args)
+	if res.Status != 200 {
+		t.Fatalf("%s%v failed: %s", fcn, params, res.Message)
+	}
+
+	return string(res.Payload)
+}
+
+func TestContractTypedTransactions(t *testing.T) {
+	stub := newContractStub(t)
+
+	invokeContract(t, stub, "Transfer", "owner", "alice", "300")
+	invokeContract(t, stub, "Approve", "alice", "bob", "100")
+	invokeContract(t, stub, "IncreaseAllowance", "alice", "bob", "50")
+
+	for _, test := range []struct {
+		fcn      string
+		params   []string
+		expected string
+	}{
+		{"BalanceOf", []string{"alice"}, "300"},
+		{"Allowance", []string{"alice", "bob"}, "150"},
+		{"TotalSupply", []string{"token"}, "1000"},
+	} {
+		if payload := invokeContract(t, stub, test.fcn, test.params...); payload != test.expected {
+			t.Errorf("%s%v: expected %s, got %s", test.fcn, test.params, test.expected, payload)
+		}
+	}
+
+	// the typed amount is checked by the contract API
+	if res := stub.MockInvoke("tx", [][]byte{[]byte("Transfer"), []byte("owner"), []byte("alice"), []byte("ten")}); res.Status == 200 {
+		t.Fatal("the transfer of the amount that is not a number must fail")
+	}
+}
+
+func TestContractDispatchesByName(t *testing.T) {
+	stub := newContractStub(t)
+
+	// the functions of Invoke are dispatched by the same names, also qualified by the contract name
+	invokeContract(t, stub, "transfer", "owner", "alice", "300")
+	invokeContract(t, stub, "ERC20Contract:transfer", "alice", "bob", "100")
+	if payload := invokeContract(t, stub, "balanceOf", "bob"); payload != "100" {
+		t.Fatalf("expected the balance 100, got %s", payload)
+	}
+
+	if res := stub.MockInvoke("tx", [][]byte{[]byte("transfer"), []byte("owner"), []byte("alice"), []byte("-1")}); res.Status == 200 {
+		t.Fatal("the error of the controller must fail the transaction")
+	}
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/store"
+	"hypherledgertest2/util"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// the range of the simple keys, the composite keys start with \x00 and are out of the range
+const (
+	firstSimpleKey = "\x01"
+	lastSimpleKey  = string(utf8.MaxRune)
+)
+
+// Audit is query function that sums the balances and compares the sum with the total supply, /
+// reporting malformed and negative balances and malformed, negative or orphaned allowances /
+// (the owner has no balance in the ledger). The balances are audited first, then the allowances, /
+// at most page size entries per page. Audit the ledger without transactions between the pages.
+// The bookmark carries only the key of the next page, the entries of the previous pages /
+// are audited again, so that a forged bookmark cannot change the sums.
+// params - tokenName, page size, bookmark(empty for the first page)
+// Returns the page of the audit report with the bookmark of the next page, empty on the last page.
+func (cc *Controller) Audit(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	tokenName := params[0]
+
+	pageSize, err := util.ConverToPositive(params[1], "pageSize")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	cursor, err := decodeAuditCursor(params[2])
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// the audit checks the layout of the ledger whatever the store of the controller is
+	erc20, err := store.NewStubStore(stub).GetMetadata(tokenName)
+	CheckErr(err, "failed to metadata.GetMetadata(tokenName)")
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+
+	report := model.AuditReport{TokenName: tokenName, TotalSupply: int(erc20.TotalSupply), Issues: []model.AuditIssue{}}
+	page := &auditPage{report: &report, startKey: cursor.Key, remaining: pageSize}
+
+	nextKey := auditBalances(stub, tokenName, page)
+	if len(nextKey) == 0 {
+		nextKey = auditAllowances(stub, page)
+	}
+
+	// compare the sum with the total supply on the last page
+	if len(nextKey) == 0 {
+		report.Complete = true
+		if report.BalanceSum != report.TotalSupply {
+			page.addIssue(true, model.AuditIssue{
+				Type:    model.SupplyMismatchIssue,
+				Key:     tokenName,
+				Value:   strconv.Itoa(report.BalanceSum),
+				Message: fmt.Sprintf("sum of balances %d != total supply %d", report.BalanceSum, report.TotalSupply)})
+		}
+		report.Consistent = report.IssueCount == 0
+	} else {
+		report.Bookmark = encodeAuditCursor(&model.AuditCursor{Key: nextKey})
+	}
+
+	reportBytes, err := json.Marshal(report)
+	CheckErr(err, "failed to json.Marshal(report)")
+
+	return fabric.Success(reportBytes)
+}
+
+// auditPage is the page of the audit from the start key, /
+// the entries before the start key are summed and counted without reporting their issues
+type auditPage struct {
+	report    *model.AuditReport
+	startKey  string
+	remaining int
+}
+
+// contains checks the key is at or after the start key of the page, /
+// the balances(simple keys) are audited before the allowances(composite keys)
+func (p *auditPage) contains(key string) bool {
+	if len(p.startKey) == 0 {
+		return true
+	}
+
+	startsAtAllowance, isAllowance := strings.HasPrefix(p.startKey, "\x00"), strings.HasPrefix(key, "\x00")
+	if startsAtAllowance != isAllowance {
+		return isAllowance
+	}
+
+	return key >= p.startKey
+}
+
+// addIssue counts the issue, and adds it to the report if the entry is in the page
+func (p *auditPage) addIssue(inPage bool, issue model.AuditIssue) {
+	p.report.IssueCount++
+	if inPage {
+		p.report.Issues = append(p.report.Issues, issue)
+	}
+}
+
+// auditBalances audits the balances, the simple keys other than the metadata, until the end of the page.
+// Returns the key of the next balance, empty if all balances are audited.
+func auditBalances(stub fabric.Stub, tokenName string, page *auditPage) string {
+	balanceIter, err := stub.GetStateByRange(firstSimpleKey, lastSimpleKey)
+	CheckErr(err, "failed to stub.GetStateByRange(firstSimpleKey, lastSimpleKey)")
+	defer balanceIter.Close()
+
+	for balanceIter.HasNext() {
+		balanceKeyValue, err := balanceIter.Next()
+		CheckErr(err, "failed to balanceIter.Next()")
+
+		address, balanceBytes := balanceKeyValue.GetKey(), balanceKeyValue.GetValue()
+		if address == tokenName {
+			continue
+		}
+		inPage := page.contains(address)
+		if inPage {
+			if page.remaining == 0 {
+				return address
+			}
+			page.remaining--
+		}
+
+		balance, err := strconv.Atoi(string(balanceBytes))
+		switch {
+		case err != nil:
+			page.addIssue(inPage, model.AuditIssue{
+				Type:    model.MalformedBalanceIssue,
+				Key:     address,
+				Value:   string(balanceBytes),
+				Message: "balance must be a number"})
+		case balance < 0:
+			page.addIssue(inPage, model.AuditIssue{
+				Type:    model.NegativeBalanceIssue,
+				Key:     address,
+				Value:   string(balanceBytes),
+				Message: "balance cannot be negative"})
+		}
+
+		// the malformed balances are not summed
+		if err == nil {
+			page.report.BalanceSum += balance
+		}
+		page.report.BalanceCount++
+	}
+
+	return ""
+}
+
+// auditAllowances audits the allowances until the end of the page.
+// Returns the key of the next allowance, empty if all allowances are audited.
+func auditAllowances(stub fabric.Stub, page *auditPage) string {
+	approvalIter, err := stub.GetStateByPartialCompositeKey("approval", []string{})
+	CheckErr(err, `failed to stub.GetStateByPartialCompositeKey("approval", []string{})`)
+	defer approvalIter.Close()
+
+	balances := store.NewStubStore(stub)
+	for approvalIter.HasNext() {
+		approvalKeyValue, err := approvalIter.Next()
+		CheckErr(err, "failed to approvalIter.Next()")
+
+		approvalKey, allowanceBytes := approvalKeyValue.GetKey(), approvalKeyValue.GetValue()
+		inPage := page.contains(approvalKey)
+		if inPage {
+			if page.remaining == 0 {
+				return approvalKey
+			}
+			page.remaining--
+		}
+		page.report.AllowanceCount++
+
+		_, addresses, err := stub.SplitCompositeKey(approvalKey)
+		if err != nil || len(addresses) != 2 {
+			page.addIssue(inPage, model.AuditIssue{
+				Type:    model.MalformedAllowanceIssue,
+				Key:     approvalKey,
+				Value:   string(allowanceBytes),
+				Message: "approval key must be of the owner and the spender"})
+			continue
+		}
+
+		allowance, err := store.ParseAllowance(allowanceBytes)
+		if err != nil {
+			page.addIssue(inPage, model.AuditIssue{
+				Type:    model.MalformedAllowanceIssue,
+				Key:     approvalKey,
+				Value:   string(allowanceBytes),
+				Message: "allowance must be a number or a json of model.Allowance"})
+			continue
+		}
+		if allowance.Amount < 0 {
+			page.addIssue(inPage, model.AuditIssue{
+				Type:    model.NegativeAllowanceIssue,
+				Key:     approvalKey,
+				Value:   string(allowanceBytes),
+				Message: fmt.Sprintf("allowance of %s to %s cannot be negative", addresses[0], addresses[1])})
+		}
+
+		// the malformed balance of the owner is reported by the balances
+		_, exists, _ := balances.GetBalance(addresses[0])
+		if !exists {
+			page.addIssue(inPage, model.AuditIssue{
+				Type:    model.OrphanedAllowanceIssue,
+				Key:     approvalKey,
+				Value:   string(allowanceBytes),
+				Message: fmt.Sprintf("owner %s of the allowance to %s has no balance in the ledger", addresses[0], addresses[1])})
+		}
+	}
+
+	return ""
+}
+
+// decodeAuditCursor decodes the bookmark of the audit, the start of the audit if it is empty
+func decodeAuditCursor(bookmark string) (*model.AuditCursor, error) {
+	cursor := model.AuditCursor{}
+	if len(bookmark) == 0 {
+		return &cursor, nil
+	}
+
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(bookmark)
+	if err == nil {
+		err = json.Unmarshal(cursorBytes, &cursor)
+	}
+	if err != nil || len(cursor.Key) == 0 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "bookmark",
+			Message:    "must be the bookmark of the previous audit page"}
+	}
+
+	return &cursor, nil
+}
+
+// encodeAuditCursor encodes the cursor to the bookmark of the next page
+func encodeAuditCursor(cursor *model.AuditCursor) string {
+	cursorBytes, err := json.Marshal(cursor)
+	CheckErr(err, "failed to json.Marshal(cursor)")
+
+	return base64.RawURLEncoding.EncodeToString(cursorBytes)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"hypherledgertest2/model"
+	"hypherledgertest2/store"
+)
+
+// maxAmount is the largest balance and total supply of the token
+const maxAmount = int(^uint(0) >> 1)
+
+// debit subtracts amount from the balance of the address, the balance must exist and cover amount.
+// Returns the balance before the change.
+func debit(balances store.BalanceStore, address string, amount int) (int, error) {
+	balance, exists, err := balances.GetBalance(address)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, &model.CustomError{
+			ErrorType:  model.GetErrorType,
+			TargetName: "balance of " + address,
+			Message:    "balance does not exist in the ledger"}
+	}
+	if balance < amount {
+		return balance, &model.CustomError{
+			ErrorType:  model.VerifyErrorType,
+			TargetName: "balance of " + address,
+			Message:    "balance must be over the amount"}
+	}
+
+	return balance, balances.PutBalance(address, balance-amount)
+}
+
+// credit adds amount to the balance of the address, zero if it does not exist. /
+// The balance must not overflow.
+// Returns the balance before the change.
+func credit(balances store.BalanceStore, address string, amount int) (int, error) {
+	balance, _, err := balances.GetBalance(address)
+	if err != nil {
+		return 0, err
+	}
+	if amount > 0 && balance > maxAmount-amount {
+		return balance, &model.CustomError{
+			ErrorType:  model.VerifyErrorType,
+			TargetName: "balance of " + address,
+			Message:    "balance overflows with the amount"}
+	}
+
+	return balance, balances.PutBalance(address, balance+amount)
+}
+
+// spendAllowance decreases the allowance of spender over the owner tokens by amount at the time now.
+// An expired allowance is zero and an infinite allowance is not decreased.
+func spendAllowance(allowances store.AllowanceStore, ownerAddress, spenderAddress string, amount int, now int64) error {
+	allowance, err := allowances.GetAllowance(ownerAddress, spenderAddress)
+	if err != nil {
+		return err
+	}
+
+	remaining := allowanceAmountAt(allowance, now)
+	if remaining < amount {
+		return &model.CustomError{
+			ErrorType:  model.VerifyErrorType,
+			TargetName: "allowance",
+			Message:    "spender's allowance must be over the transfered money"}
+	}
+	if allowance.Amount == InfiniteAllowance {
+		return nil
+	}
+
+	// keep the expiry
+	allowance.Amount = remaining - amount
+	return allowances.PutAllowance(ownerAddress, spenderAddress, allowance)
+}
+
+// isAllowanceExpiredAt checks the expiry of the allowance at the time now
+func isAllowanceExpiredAt(allowance *model.Allowance, now int64) bool {
+	return allowance.ExpiresAt != 0 && now >= allowance.ExpiresAt
+}
+
+// allowanceAmountAt gets the amount of the allowance at the time now, zero if expired
+func allowanceAmountAt(allowance *model.Allowance, now int64) int {
+	if isAllowanceExpiredAt(allowance, now) {
+		return 0
+	}
+
+	return allowance.Amount
+}
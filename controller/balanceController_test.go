@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"hypherledgertest2/model"
+	"hypherledgertest2/store"
+	"strconv"
+	"testing"
+)
+
+func TestDebitCredit(t *testing.T) {
+	balances := store.NewMemoryStore()
+	balances.PutBalance("alice", 100)
+
+	if _, err := debit(balances, "bob", 1); err == nil {
+		t.Fatal("debit of a balance that does not exist must fail")
+	}
+	if _, err := debit(balances, "alice", 101); err == nil {
+		t.Fatal("debit over the balance must fail")
+	}
+
+	before, err := debit(balances, "alice", 40)
+	if err != nil || before != 100 {
+		t.Fatal("expected the balance 100 before the debit, got", before, err)
+	}
+	before, err = credit(balances, "bob", 40)
+	if err != nil || before != 0 {
+		t.Fatal("expected the balance 0 before the credit, got", before, err)
+	}
+
+	alice, _, _ := balances.GetBalance("alice")
+	bob, _, _ := balances.GetBalance("bob")
+	if alice != 60 || bob != 40 {
+		t.Fatal("expected the balances 60 and 40, got", alice, bob)
+	}
+
+	if _, err := credit(balances, "bob", maxAmount-39); err == nil {
+		t.Fatal("credit overflowing the balance must fail")
+	}
+	if bob, _, _ := balances.GetBalance("bob"); bob != 40 {
+		t.Fatal("the overflowing credit must not change the balance, got", bob)
+	}
+}
+
+func TestSpendAllowance(t *testing.T) {
+	allowances := store.NewMemoryStore()
+	allowances.PutAllowance("alice", "bob", &model.Allowance{Amount: 100, ExpiresAt: 1000})
+	allowances.PutAllowance("alice", "carol", &model.Allowance{Amount: InfiniteAllowance})
+
+	if err := spendAllowance(allowances, "alice", "bob", 101, 0); err == nil {
+		t.Fatal("spending over the allowance must fail")
+	}
+	if err := spendAllowance(allowances, "alice", "bob", 30, 999); err != nil {
+		t.Fatal(err)
+	}
+	if allowance, _ := allowances.GetAllowance("alice", "bob"); allowance.Amount != 70 || allowance.ExpiresAt != 1000 {
+		t.Fatal("expected the allowance 70 keeping the expiry, got", allowance)
+	}
+	if err := spendAllowance(allowances, "alice", "bob", 1, 1000); err == nil {
+		t.Fatal("spending an expired allowance must fail")
+	}
+
+	if err := spendAllowance(allowances, "alice", "carol", 1000000, 0); err != nil {
+		t.Fatal(err)
+	}
+	if allowance, _ := allowances.GetAllowance("alice", "carol"); allowance.Amount != InfiniteAllowance {
+		t.Fatal("infinite allowance must not be decreased, got", allowance.Amount)
+	}
+
+	if err := spendAllowance(allowances, "alice", "dave", 1, 0); err == nil {
+		t.Fatal("spending without the allowance must fail")
+	}
+}
+
+func BenchmarkDebitCredit(b *testing.B) {
+	balances := store.NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		balances.PutBalance(strconv.Itoa(i), b.N)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sender, recipient := strconv.Itoa(i%100), strconv.Itoa((i+1)%100)
+		if _, err := debit(balances, sender, 1); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := credit(balances, recipient, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"hypherledgertest2/fabric"
+)
+
+// TransferAndCall is invoke function that moves amount token /
+// from the caller's address to the account of the recipient chaincode /
+// and notifies it with onTokenReceived(sender, amount, data). /
+// The whole transaction is reverted if the callback rejects /
+// params - caller's address, recipient chaincode name, amount of token, data.
+func (cc *Controller) TransferAndCall(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is four
+	if len(params) != 4 {
+		return fabric.Error("the number of params must be four")
+	}
+
+	callerAddress, recipientChaincode, amount, data := params[0], params[1], params[2], params[3]
+
+	// transfer to the chaincode's account
+	transferResponse := cc.Transfer(stub, []string{callerAddress, recipientChaincode, amount})
+	if transferResponse.Status == SpendingLimitExceededStatus {
+		return transferResponse
+	}
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.Transfer([]string{callerAddress, recipientChaincode, amount}), err: ` + transferResponse.GetMessage())
+	}
+
+	// notify the recipient chaincode
+	callbackResponse := invokeChaincode(stub, recipientChaincode, "onTokenReceived", callerAddress, amount, data)
+	if callbackResponse.GetStatus() >= 400 {
+		return fabric.Error(`onTokenReceived is rejected by ` + recipientChaincode + `, err: ` + callbackResponse.GetMessage())
+	}
+
+	return fabric.Success([]byte("transferAndCall func success"))
+}
+
+// ApproveAndCall is invoke function that sets amount as the allowance /
+// of the spender chaincode over the owner tokens /
+// and notifies it with onApprovalReceived(owner, amount, data). /
+// The whole transaction is reverted if the callback rejects /
+// params - owner's address, spender chaincode name, amount of token, data.
+func (cc *Controller) ApproveAndCall(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is four
+	if len(params) != 4 {
+		return fabric.Error("the number of params must be four")
+	}
+
+	ownerAddress, spenderChaincode, amount, data := params[0], params[1], params[2], params[3]
+
+	// approve the chaincode's account
+	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderChaincode, amount})
+	if approveResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.Approve([]string{ownerAddress, spenderChaincode, amount}), err: ` + approveResponse.GetMessage())
+	}
+
+	// notify the spender chaincode
+	callbackResponse := invokeChaincode(stub, spenderChaincode, "onApprovalReceived", ownerAddress, amount, data)
+	if callbackResponse.GetStatus() >= 400 {
+		return fabric.Error(`onApprovalReceived is rejected by ` + spenderChaincode + `, err: ` + callbackResponse.GetMessage())
+	}
+
+	return fabric.Success([]byte("approveAndCall func success"))
+}
+
+// invokeChaincode invokes the function of another chaincode in the same channel
+func invokeChaincode(stub fabric.Stub, chaincodeName, function string, params ...string) fabric.Response {
+	// make arguments
+	args := [][]byte{[]byte(function)}
+	for _, param := range params {
+		args = append(args, []byte(param))
+	}
+
+	return stub.InvokeChaincode(chaincodeName, args, stub.GetChannelID())
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"hypherledgertest2/fabric"
+	"reflect"
+	"testing"
+)
+
+// installMarket installs the chaincode market recording its callbacks, /
+// which rejects the data "reject"
+func installMarket(l *testLedger) *[][]string {
+	calls := [][]string{}
+	l.chaincodes["market"] = func(args []string) fabric.Response {
+		calls = append(calls, args)
+		if args[len(args)-1] == "reject" {
+			return fabric.Error("market rejects the tokens")
+		}
+
+		return fabric.Success(nil)
+	}
+
+	return &calls
+}
+
+func TestTransferAndCall(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	calls := installMarket(l)
+
+	l.mustInvoke("alice", l.cc.TransferAndCall, "alice", "market", "100", "order-1")
+	if expected := [][]string{{"onTokenReceived", "alice", "100", "order-1"}}; !reflect.DeepEqual(*calls, expected) {
+		t.Fatalf("expected the callback %v, got %v", expected, *calls)
+	}
+	l.expectBalances(map[string]int{"alice": 900, "market": 100})
+
+	// the rejected callback reverts the transfer
+	l.mustFail("alice", "onTokenReceived is rejected by market", l.cc.TransferAndCall, "alice", "market", "100", "reject")
+	l.mustFail("alice", "chaincode other is not installed", l.cc.TransferAndCall, "alice", "other", "100", "order-2")
+	l.expectBalances(map[string]int{"alice": 900, "market": 100, "other": 0})
+
+	// the callback is not invoked when the transfer fails
+	l.mustFail("alice", "caller's amount must be over the transfered money", l.cc.TransferAndCall, "alice", "market", "1000", "order-3")
+	if len(*calls) != 2 {
+		t.Fatalf("expected no callback of the failed transfer, got %v", *calls)
+	}
+}
+
+func TestApproveAndCall(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	calls := installMarket(l)
+
+	l.mustInvoke("alice", l.cc.ApproveAndCall, "alice", "market", "300", "listing-1")
+	if expected := [][]string{{"onApprovalReceived", "alice", "300", "listing-1"}}; !reflect.DeepEqual(*calls, expected) {
+		t.Fatalf("expected the callback %v, got %v", expected, *calls)
+	}
+
+	// the rejected callback reverts the approval
+	l.mustFail("alice", "onApprovalReceived is rejected by market", l.cc.ApproveAndCall, "alice", "market", "500", "reject")
+	if allowance := l.mustInvoke("", l.cc.Allowance, "alice", "market"); allowance != "300" {
+		t.Fatalf("expected the allowance 300, got %s", allowance)
+	}
+}
@@ -1,42 +1,115 @@
 package controller
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"hypherledgertest2/fabric"
 	"hypherledgertest2/model"
+	"hypherledgertest2/store"
+	"hypherledgertest2/util"
 	"log"
-	"strconv"
-
-	"github.com/hyperledger/fabric/core/chaincode/shim"
-	sc "github.com/hyperledger/fabric/protos/peer"
+	"math"
+	"sort"
 )
 
+// InfiniteAllowance is the allowance that is not decreased by transferFrom
+const InfiniteAllowance = math.MaxInt64
+
 // Controller is ...
 type Controller struct {
+	// newStore makes the store of the balances, the allowances and the metadata of the transaction
+	newStore func(stub fabric.Stub) store.Store
 }
 
-// NewController ...
+// NewController makes the controller keeping the token state in the ledger
 func NewController() *Controller {
-	return &Controller{}
+	return NewControllerWithStore(func(stub fabric.Stub) store.Store {
+		return store.NewStubStore(stub)
+	})
+}
+
+// NewControllerWithStore makes the controller keeping the token state in the store made per transaction, /
+// e.g. store.MemoryStore to run the business logic without the ledger
+func NewControllerWithStore(newStore func(stub fabric.Stub) store.Store) *Controller {
+	return &Controller{newStore: newStore}
+}
+
+// getStore gets the store of the token state of the transaction
+func (cc *Controller) getStore(stub fabric.Stub) store.Store {
+	return cc.newStore(stub)
 }
 
-// CheckErr is ...
+// CheckErr panics with the message if err is not nil, /
+// so that the unexpected error fails the transaction instead of exiting the process hosting the chaincode
 func CheckErr(err error, errMessage string) {
 	if err != nil {
-		log.Fatalln(errMessage)
+		log.Panicln(errMessage + ", err: " + err.Error())
+	}
+}
+
+// getTxUnixTime is a helper function
+// Returns the transaction timestamp in unix seconds.
+func getTxUnixTime(stub fabric.Stub) int64 {
+	txTimestamp, err := stub.GetTxTimestamp()
+	CheckErr(err, "failed to stub.GetTxTimestamp()")
+
+	return txTimestamp.GetSeconds()
+}
+
+// actingStub is the stub of a transaction acting for the signer of a signed payload
+type actingStub struct {
+	fabric.Stub
+	actingAddress string
+}
+
+// getCallerAddress is a helper function
+// Returns the address derived from the public key of the creator's certificate, /
+// or the signer's address of the signed payload.
+func getCallerAddress(stub fabric.Stub) (string, error) {
+	if signedStub, ok := stub.(*actingStub); ok {
+		return signedStub.actingAddress, nil
+	}
+
+	cert, err := fabric.GetX509Certificate(stub)
+	if err != nil {
+		return "", err
+	}
+	if cert == nil {
+		return "", &model.CustomError{
+			ErrorType:  model.GetErrorType,
+			TargetName: "creator",
+			Message:    "creator has no certificate"}
 	}
+
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return hex.EncodeToString(hash[:20]), nil
 }
 
 // Init is ...
-func (cc *Controller) Init(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+// On the upgrade of the deployed token, the state is kept and migrated to the latest schema version.
+func (cc *Controller) Init(stub fabric.Stub, params []string) fabric.Response {
 	tokenName, symbol, owner, amount := params[0], params[1], params[2], params[3]
 
-	// check amount is unsigned int
-	amountUint, err := strconv.ParseUint(amount, 10, 64)
-	CheckErr(err, "amount must be a number or cannot be negative")
+	// check amount is integer & not negative, the invalid amount fails the transaction
+	amountInt, err := util.ConvertToNonNegative(amount, "amount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
 
 	// tokenName, symbol, owner cannot be empty
 	if len(tokenName) == 0 || len(symbol) == 0 || len(owner) == 0 {
-		return shim.Error("tokenName, symbol, owner cannont be empty")
+		return fabric.Error("tokenName, symbol, owner cannont be empty")
+	}
+
+	// Init runs again on the upgrade, keep the state of the deployed token. /
+	// The legacy state is found whatever the token name of the upgrade is.
+	schemaVersion := getSchemaVersion(stub)
+	if schemaVersion == nil {
+		schemaVersion = getLegacySchemaVersion(stub)
+	}
+	if schemaVersion != nil {
+		return upgrade(stub, tokenName, schemaVersion)
 	}
 
 	// make meta data
@@ -44,19 +117,87 @@ func (cc *Controller) Init(stub shim.ChaincodeStubInterface, params []string) sc
 		Name:        tokenName,
 		Symbol:      symbol,
 		Owner:       owner,
-		TotalSupply: amountUint}
+		TotalSupply: uint64(amountInt)}
 
-	erc20Bytes, err := json.Marshal(erc20)
-	CheckErr(err, "failed to Marshal erc20")
+	// save token & owner's balance to database
+	tokenStore := cc.getStore(stub)
+	err = tokenStore.PutMetadata(&erc20)
+	CheckErr(err, "failed to tokenStore.PutMetadata(&erc20)")
 
-	// save token to database
-	err = stub.PutState(tokenName, erc20Bytes)
-	CheckErr(err, "failed to PutState erc20")
+	err = tokenStore.PutBalance(owner, amountInt)
+	CheckErr(err, "failed to tokenStore.PutBalance(owner, amount)")
 
-	// save owner's balance
-	err = stub.PutState(owner, []byte(amount))
-	CheckErr(err, "failed to PutState erc20")
+	// the new state is written in the latest layout
+	putSchemaVersion(stub, tokenName)
 
 	// response
-	return shim.Success(nil)
+	return fabric.Success(nil)
+}
+
+// getSigningDomain gets the chaincode name and the token name of the deployment, /
+// so that a signed message cannot be replayed on another chaincode or token of the channel
+func getSigningDomain(stub fabric.Stub) (string, string, error) {
+	chaincodeName, err := fabric.GetChaincodeName(stub)
+	if err != nil {
+		return "", "", err
+	}
+
+	tokenName := ""
+	if schemaVersion := getSchemaVersion(stub); schemaVersion != nil {
+		tokenName = schemaVersion.TokenName
+	}
+
+	return chaincodeName, tokenName, nil
+}
+
+// getMetadata gets the token metadata from the store, nil if it does not exist
+func (cc *Controller) getMetadata(stub fabric.Stub, tokenName string) *model.ERC20Metadata {
+	erc20, err := cc.getStore(stub).GetMetadata(tokenName)
+	CheckErr(err, "failed to metadata.GetMetadata(tokenName)")
+
+	return erc20
+}
+
+// putMetadata saves the token metadata to the store
+func (cc *Controller) putMetadata(stub fabric.Stub, erc20 *model.ERC20Metadata) {
+	err := cc.getStore(stub).PutMetadata(erc20)
+	CheckErr(err, "failed to metadata.PutMetadata(erc20)")
+}
+
+// getPage reads at most pageSize entries of the composite key namespace, /
+// starting from the bookmark (the key of the first entry of the page).
+// Returns the entries and the bookmark of the next page, empty if it is the last page.
+func getPage(stub fabric.Stub, objectType string, attributes []string, pageSize int, bookmark string) ([]*fabric.KV, string) {
+	iter, err := stub.GetStateByPartialCompositeKey(objectType, attributes)
+	CheckErr(err, "failed to stub.GetStateByPartialCompositeKey(objectType, attributes)")
+	defer iter.Close()
+
+	page := []*fabric.KV{}
+	for iter.HasNext() {
+		keyValue, err := iter.Next()
+		CheckErr(err, "failed to iter.Next()")
+
+		// keys are sorted, skip the entries before the bookmark
+		if keyValue.GetKey() < bookmark {
+			continue
+		}
+
+		if len(page) == pageSize {
+			return page, keyValue.GetKey()
+		}
+		page = append(page, keyValue)
+	}
+
+	return page, ""
+}
+
+// sortedAddresses gets the addresses of the changes in order, so the writes are deterministic
+func sortedAddresses(changes map[string]int) []string {
+	addresses := make([]string, 0, len(changes))
+	for address := range changes {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
 }
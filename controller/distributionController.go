@@ -0,0 +1,340 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/util"
+	"math/big"
+	"strconv"
+)
+
+// DistributionEscrowAddress is the address holding the payouts until they are claimed
+const DistributionEscrowAddress = "distributionEscrow"
+
+// CreateDistribution is invoke function that escrows amount token of the caller /
+// to be claimed by the holders proportional to their balances at the snapshot. /
+// The payout is paid in this token, the escrow of another chaincode could not be released safely /
+// params - tokenName, snapshot id, amount of payout, /
+// payout chaincode name (must be empty), claim deadline(unix seconds).
+// Returns the id of the distribution.
+func (cc *Controller) CreateDistribution(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is five
+	if len(params) != 5 {
+		return fabric.Error("the number of params must be five")
+	}
+
+	tokenName, amount, chaincodeName := params[0], params[2], params[3]
+
+	distributorAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	snapshotID, err := checkSnapshotID(stub, params[1])
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// check the claim deadline is in the future
+	claimDeadline, err := strconv.ParseInt(params[4], 10, 64)
+	if err != nil {
+		return fabric.Error("claim deadline must be a unix timestamp")
+	}
+	if claimDeadline <= getTxUnixTime(stub) {
+		return fabric.Error("claim deadline must be in the future")
+	}
+
+	// check amount is integer & positive
+	amountInt, err := util.ConverToPositive(amount, "distributionAmount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// the escrow of another chaincode would be released by its unauthenticated transfer
+	if len(chaincodeName) != 0 {
+		return fabric.Error("payout chaincode must be empty, the payout is paid in this token")
+	}
+
+	// check someone holds the token at the snapshot
+	totalSupply, err := cc.distributionTotalSupply(stub, tokenName, snapshotID)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+	if totalSupply == 0 {
+		return fabric.Error("total supply at the snapshot is zero")
+	}
+
+	// escrow the payout
+	transferResponse := cc.Transfer(stub, []string{distributorAddress, DistributionEscrowAddress, amount})
+	if transferResponse.Status == SpendingLimitExceededStatus {
+		return transferResponse
+	}
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.Transfer([]string{distributorAddress, DistributionEscrowAddress, amount}), err: ` + transferResponse.GetMessage())
+	}
+
+	// save distribution
+	distribution := model.NewDistribution(stub.GetTxID(), tokenName, snapshotID, distributorAddress, amountInt, claimDeadline)
+	putDistribution(stub, distribution)
+
+	return fabric.Success([]byte(distribution.ID))
+}
+
+// ClaimDistribution is invoke function that pays the holder's share of the distribution /
+// params - holder's address, distribution id.
+// Returns the claimed amount.
+func (cc *Controller) ClaimDistribution(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	holderAddress, distributionID := params[0], params[1]
+
+	// the escrowed tokens are not held by the holders, their share would be locked in the escrow
+	if isEscrowAddress(holderAddress) {
+		return fabric.Error("escrow account cannot claim the distribution")
+	}
+
+	distribution := getDistribution(stub, distributionID)
+	if distribution == nil {
+		return fabric.Error("distribution does not exist in the ledger")
+	}
+	if distribution.Closed {
+		return fabric.Error("distribution is closed")
+	}
+
+	// check the holder did not claim yet
+	claimKey, err := stub.CreateCompositeKey("distributionClaim", []string{distributionID, holderAddress})
+	CheckErr(err, "failed to make a composite key for distributionClaim")
+
+	claimBytes, err := stub.GetState(claimKey)
+	CheckErr(err, "failed to stub.GetState(claimKey)")
+	if claimBytes != nil {
+		return fabric.Error("holder already claimed the distribution")
+	}
+
+	// calculate the share
+	share, err := cc.distributionShare(stub, distribution, holderAddress)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+	if share == 0 {
+		return fabric.Error("there is no share for the holder")
+	}
+
+	// pay the share
+	response := cc.payDistribution(stub, holderAddress, share)
+	if response.Status >= 400 {
+		return response
+	}
+
+	// save claimed amount
+	err = stub.PutState(claimKey, []byte(strconv.Itoa(share)))
+	CheckErr(err, "failed to stub.PutState(claimKey, share)")
+
+	distribution.Claimed += share
+	putDistribution(stub, distribution)
+
+	return fabric.Success([]byte(strconv.Itoa(share)))
+}
+
+// CloseDistribution is invoke function that closes the distribution after the claim deadline /
+// and refunds the unclaimed amount, including the rounding remainder, to the distributor. /
+// The caller must be the distributor /
+// params - distribution id.
+// Returns the refunded amount.
+func (cc *Controller) CloseDistribution(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	distributionID := params[0]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	distribution := getDistribution(stub, distributionID)
+	if distribution == nil {
+		return fabric.Error("distribution does not exist in the ledger")
+	}
+	if distribution.Distributor != callerAddress {
+		return fabric.Error("only the distributor can close the distribution")
+	}
+	if distribution.Closed {
+		return fabric.Error("distribution is already closed")
+	}
+
+	// the holders can claim until the deadline
+	if getTxUnixTime(stub) < distribution.ClaimDeadline {
+		return fabric.Error("distribution cannot be closed before the claim deadline")
+	}
+
+	// refund the remainder
+	remainder := distribution.Amount - distribution.Claimed
+	if remainder > 0 {
+		response := cc.payDistribution(stub, distribution.Distributor, remainder)
+		if response.Status >= 400 {
+			return response
+		}
+	}
+
+	distribution.Closed = true
+	putDistribution(stub, distribution)
+
+	return fabric.Success([]byte(strconv.Itoa(remainder)))
+}
+
+// ClaimableDistribution is query function
+// params - holder's address, distribution id
+// Returns the amount the holder can claim, 0 if already claimed.
+func (cc *Controller) ClaimableDistribution(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	holderAddress, distributionID := params[0], params[1]
+
+	distribution := getDistribution(stub, distributionID)
+	if distribution == nil {
+		return fabric.Error("distribution does not exist in the ledger")
+	}
+
+	claimKey, err := stub.CreateCompositeKey("distributionClaim", []string{distributionID, holderAddress})
+	CheckErr(err, "failed to make a composite key for distributionClaim")
+
+	claimBytes, err := stub.GetState(claimKey)
+	CheckErr(err, "failed to stub.GetState(claimKey)")
+	if claimBytes != nil || distribution.Closed || isEscrowAddress(holderAddress) {
+		return fabric.Success([]byte("0"))
+	}
+
+	share, err := cc.distributionShare(stub, distribution, holderAddress)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	return fabric.Success([]byte(strconv.Itoa(share)))
+}
+
+// GetDistribution is query function
+// params - distribution id
+// Returns the distribution.
+func (cc *Controller) GetDistribution(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	distribution := getDistribution(stub, params[0])
+	if distribution == nil {
+		return fabric.Error("distribution does not exist in the ledger")
+	}
+
+	distributionBytes, err := json.Marshal(distribution)
+	CheckErr(err, "failed to json.Marshal(distribution)")
+
+	return fabric.Success(distributionBytes)
+}
+
+// distributionTotalSupply gets the total supply held by the holders at the snapshot, /
+// the balances of the escrow accounts are not claimable
+func (cc *Controller) distributionTotalSupply(stub fabric.Stub, tokenName string, snapshotID int) (int, error) {
+	totalSupplyResponse := cc.TotalSupplyAt(stub, []string{tokenName, strconv.Itoa(snapshotID)})
+	if totalSupplyResponse.Status >= 400 {
+		return 0, &model.CustomError{
+			ErrorType:  model.GetErrorType,
+			TargetName: "totalSupplyAt",
+			Message:    totalSupplyResponse.GetMessage()}
+	}
+
+	totalSupply, err := strconv.Atoi(string(totalSupplyResponse.GetPayload()))
+	CheckErr(err, "failed to strconv.Atoi(string(totalSupplyResponse.GetPayload()))")
+
+	for _, escrowAddress := range escrowAddresses {
+		escrowBalance, err := cc.distributionBalance(stub, escrowAddress, snapshotID)
+		if err != nil {
+			return 0, err
+		}
+		totalSupply -= escrowBalance
+	}
+
+	return totalSupply, nil
+}
+
+// distributionBalance gets the balance of the address at the snapshot
+func (cc *Controller) distributionBalance(stub fabric.Stub, address string, snapshotID int) (int, error) {
+	balanceResponse := cc.BalanceOfAt(stub, []string{address, strconv.Itoa(snapshotID)})
+	if balanceResponse.Status >= 400 {
+		return 0, &model.CustomError{
+			ErrorType:  model.GetErrorType,
+			TargetName: "balanceOfAt",
+			Message:    balanceResponse.GetMessage()}
+	}
+
+	balance, err := strconv.Atoi(string(balanceResponse.GetPayload()))
+	CheckErr(err, "failed to strconv.Atoi(string(balanceResponse.GetPayload()))")
+
+	return balance, nil
+}
+
+// distributionShare calculates amount * balance / totalSupply at the snapshot, rounded down
+func (cc *Controller) distributionShare(stub fabric.Stub, distribution *model.Distribution, holderAddress string) (int, error) {
+	totalSupply, err := cc.distributionTotalSupply(stub, distribution.TokenName, distribution.SnapshotID)
+	if err != nil {
+		return 0, err
+	}
+
+	balance, err := cc.distributionBalance(stub, holderAddress, distribution.SnapshotID)
+	if err != nil {
+		return 0, err
+	}
+
+	share := new(big.Int).Mul(big.NewInt(int64(distribution.Amount)), big.NewInt(int64(balance)))
+	share.Quo(share, big.NewInt(int64(totalSupply)))
+
+	return int(share.Int64()), nil
+}
+
+// payDistribution transfers amount from the escrow
+func (cc *Controller) payDistribution(stub fabric.Stub, recipientAddress string, amount int) fabric.Response {
+	transferResponse := cc.transfer(stub, []string{DistributionEscrowAddress, recipientAddress, strconv.Itoa(amount)})
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.transfer([]string{DistributionEscrowAddress, recipientAddress, amount}), err: ` + transferResponse.GetMessage())
+	}
+
+	return transferResponse
+}
+
+// getDistribution gets the distribution from the ledger, nil if it does not exist
+func getDistribution(stub fabric.Stub, distributionID string) *model.Distribution {
+	distributionKey, err := stub.CreateCompositeKey("distribution", []string{distributionID})
+	CheckErr(err, "failed to make a composite key for distribution")
+
+	distributionBytes, err := stub.GetState(distributionKey)
+	CheckErr(err, "failed to stub.GetState(distributionKey)")
+	if distributionBytes == nil {
+		return nil
+	}
+
+	distribution := model.Distribution{}
+	err = json.Unmarshal(distributionBytes, &distribution)
+	CheckErr(err, "failed to json.Unmarshal(distributionBytes, &distribution)")
+
+	return &distribution
+}
+
+// putDistribution saves the distribution to the ledger
+func putDistribution(stub fabric.Stub, distribution *model.Distribution) {
+	distributionKey, err := stub.CreateCompositeKey("distribution", []string{distribution.ID})
+	CheckErr(err, "failed to make a composite key for distribution")
+
+	distributionBytes, err := json.Marshal(distribution)
+	CheckErr(err, "failed to json.Marshal(distribution)")
+
+	err = stub.PutState(distributionKey, distributionBytes)
+	CheckErr(err, "failed to stub.PutState(distributionKey, distributionBytes)")
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+	"testing"
+)
+
+// newDistributionLedger takes the snapshot of the owner 600 and alice 400
+func newDistributionLedger(t *testing.T) (*testLedger, string) {
+	l := newTokenLedger(t, "owner", 1000)
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "400")
+
+	return l, l.mustInvoke("owner", l.cc.Snapshot, "token")
+}
+
+func TestDistributionCreateAsCaller(t *testing.T) {
+	l, snapshotID := newDistributionLedger(t)
+	deadline := strconv.FormatInt(l.now+3600, 10)
+
+	// the distributor is the caller, not a param
+	l.mustFail("bob", "caller's balance does not exist", l.cc.CreateDistribution, "token", snapshotID, "100", "", deadline)
+	l.mustFail("owner", "claim deadline must be in the future", l.cc.CreateDistribution, "token", snapshotID, "100", "", strconv.FormatInt(l.now, 10))
+	l.mustFail("owner", "claim deadline must be a unix timestamp", l.cc.CreateDistribution, "token", snapshotID, "100", "", "tomorrow")
+
+	distributionID := l.mustInvoke("owner", l.cc.CreateDistribution, "token", snapshotID, "100", "", deadline)
+	l.expectBalances(map[string]int{"owner": 500, DistributionEscrowAddress: 100})
+
+	distribution := model.Distribution{}
+	json.Unmarshal([]byte(l.mustInvoke("", l.cc.GetDistribution, distributionID)), &distribution)
+	if distribution.Distributor != "owner" || distribution.ClaimDeadline != l.now+3600 {
+		t.Fatalf("expected the distribution of the caller with the deadline, got %+v", distribution)
+	}
+}
+
+func TestDistributionClose(t *testing.T) {
+	l, snapshotID := newDistributionLedger(t)
+	distributionID := l.mustInvoke("owner", l.cc.CreateDistribution, "token", snapshotID, "100", "", strconv.FormatInt(l.now+3600, 10))
+
+	if claimed := l.mustInvoke("", l.cc.ClaimDistribution, "alice", distributionID); claimed != "40" {
+		t.Fatalf("expected the claim of 40, got %s", claimed)
+	}
+
+	// the holders can claim until the deadline
+	l.mustFail("owner", "distribution cannot be closed before the claim deadline", l.cc.CloseDistribution, distributionID)
+
+	l.now += 3600
+	l.mustFail("alice", "only the distributor can close the distribution", l.cc.CloseDistribution, distributionID)
+	l.mustFail("owner", "the number of params must be one", l.cc.CloseDistribution, "owner", distributionID)
+	if refund := l.mustInvoke("owner", l.cc.CloseDistribution, distributionID); refund != "60" {
+		t.Fatalf("expected the refund of 60, got %s", refund)
+	}
+	l.mustFail("owner", "distribution is already closed", l.cc.CloseDistribution, distributionID)
+	l.mustFail("", "distribution is closed", l.cc.ClaimDistribution, "owner", distributionID)
+
+	l.expectBalances(map[string]int{"owner": 560, "alice": 440, DistributionEscrowAddress: 0})
+}
+
+func TestDistributionExcludesEscrows(t *testing.T) {
+	l, snapshotID := newDistributionLedger(t)
+	deadline := strconv.FormatInt(l.now+3600, 10)
+	l.mustInvoke("owner", l.cc.CreateDistribution, "token", snapshotID, "100", "", deadline)
+
+	// the escrowed 100 of the first distribution is not held by the holders at the snapshot
+	snapshotID = l.mustInvoke("owner", l.cc.Snapshot, "token")
+	distributionID := l.mustInvoke("owner", l.cc.CreateDistribution, "token", snapshotID, "90", "", deadline)
+
+	for _, escrowAddress := range escrowAddresses {
+		l.mustFail("", "escrow account cannot claim the distribution", l.cc.ClaimDistribution, escrowAddress, distributionID)
+		if claimable := l.mustInvoke("", l.cc.ClaimableDistribution, escrowAddress, distributionID); claimable != "0" {
+			t.Fatalf("expected nothing claimable by %s, got %s", escrowAddress, claimable)
+		}
+	}
+
+	// 90 * 400 / 900
+	if claimed := l.mustInvoke("", l.cc.ClaimDistribution, "alice", distributionID); claimed != "40" {
+		t.Fatalf("expected the claim of 40, got %s", claimed)
+	}
+	l.expectBalances(map[string]int{"owner": 410, "alice": 440, DistributionEscrowAddress: 150})
+}
+
+func TestDistributionInAnotherChaincode(t *testing.T) {
+	l, snapshotID := newDistributionLedger(t)
+
+	invoked := false
+	l.chaincodes["payout"] = func(args []string) fabric.Response {
+		invoked = true
+		return fabric.Success(nil)
+	}
+
+	// the escrow in the payout chaincode would be released by anyone calling its transfer
+	l.mustFail("owner", "payout chaincode must be empty", l.cc.CreateDistribution, "token", snapshotID, "100", "payout", strconv.FormatInt(l.now+3600, 10))
+	if invoked {
+		t.Fatal("the payout chaincode must not be invoked")
+	}
+	l.expectBalances(map[string]int{"owner": 600, "alice": 400, DistributionEscrowAddress: 0})
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/util"
+	"math/big"
+)
+
+// SetFeeConfig is invoke function that sets the transfer fee schedule /
+// The caller must be the owner of the token /
+// params - tokenName, fee config json(model.FeeConfig).
+func (cc *Controller) SetFeeConfig(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	tokenName, feeConfigJSON := params[0], params[1]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the caller is the owner of the token
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+	if erc20.Owner != callerAddress {
+		return fabric.Error("only the owner can set the fee config")
+	}
+
+	feeConfig, err := convertFeeConfig(feeConfigJSON)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+	putFeeConfig(stub, feeConfig)
+
+	return fabric.Success([]byte("setFeeConfig func success"))
+}
+
+// GetFeeConfig is query function
+// Returns the transfer fee schedule
+func (cc *Controller) GetFeeConfig(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 0 {
+		return fabric.Error("the number of params must be zero")
+	}
+
+	feeConfigBytes, err := json.Marshal(getFeeConfig(stub))
+	CheckErr(err, "failed to json.Marshal(feeConfig)")
+
+	return fabric.Success(feeConfigBytes)
+}
+
+// QuoteTransfer is query function
+// params - amount of token
+// Returns the net and fee amounts of a transfer between non-exempt accounts
+func (cc *Controller) QuoteTransfer(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	amountInt, err := util.ConverToPositive(params[0], "quoteAmount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	fee := calculateFee(getFeeConfig(stub), amountInt)
+	if fee > amountInt {
+		return fabric.Error("amount must be over the fee")
+	}
+
+	quoteBytes, err := json.Marshal(model.TransferQuote{Amount: amountInt, Net: amountInt - fee, Fee: fee})
+	CheckErr(err, "failed to json.Marshal(quote)")
+
+	return fabric.Success(quoteBytes)
+}
+
+// transferFee calculates the fee of the transfer, 0 if the sender or the recipient is exempt
+func transferFee(stub fabric.Stub, senderAddress, recipientAddress string, amount int) (int, string) {
+	feeConfig := getFeeConfig(stub)
+	if len(feeConfig.Collector) == 0 {
+		return 0, ""
+	}
+
+	// the escrows of the chaincode never pay the fee
+	exempt := append([]string{feeConfig.Collector}, escrowAddresses...)
+	exempt = append(exempt, feeConfig.Exempt...)
+	for _, address := range exempt {
+		if address == senderAddress || address == recipientAddress {
+			return 0, feeConfig.Collector
+		}
+	}
+
+	return calculateFee(feeConfig, amount), feeConfig.Collector
+}
+
+// calculateFee calculates flat + amount * basisPoints / 10000 bounded by min & max
+func calculateFee(feeConfig *model.FeeConfig, amount int) int {
+	fee := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(feeConfig.BasisPoints)))
+	fee.Quo(fee, big.NewInt(10000))
+
+	feeInt := feeConfig.Flat + int(fee.Int64())
+	if feeInt < feeConfig.Min {
+		feeInt = feeConfig.Min
+	}
+	if feeConfig.Max > 0 && feeInt > feeConfig.Max {
+		feeInt = feeConfig.Max
+	}
+
+	return feeInt
+}
+
+// convertFeeConfig converts and checks the fee config json
+func convertFeeConfig(feeConfigJSON string) (*model.FeeConfig, error) {
+	feeConfig := model.FeeConfig{}
+	err := json.Unmarshal([]byte(feeConfigJSON), &feeConfig)
+	if err != nil {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "feeConfig",
+			Message:    "must be a json of model.FeeConfig"}
+	}
+
+	if feeConfig.Flat < 0 || feeConfig.Min < 0 || feeConfig.Max < 0 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "feeConfig",
+			Message:    "flat, min, max cannot be negative"}
+	}
+	if feeConfig.BasisPoints < 0 || feeConfig.BasisPoints > 10000 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "feeConfig",
+			Message:    "basisPoints must be between 0 and 10000"}
+	}
+	if feeConfig.Max > 0 && feeConfig.Min > feeConfig.Max {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "feeConfig",
+			Message:    "min cannot be over max"}
+	}
+	if len(feeConfig.Collector) == 0 && (feeConfig.Flat > 0 || feeConfig.BasisPoints > 0 || feeConfig.Min > 0) {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "feeConfig",
+			Message:    "collector cannot be empty"}
+	}
+
+	return &feeConfig, nil
+}
+
+// getFeeConfig gets the fee config, no fee if not set
+func getFeeConfig(stub fabric.Stub) *model.FeeConfig {
+	feeConfigKey, err := stub.CreateCompositeKey("feeConfig", []string{})
+	CheckErr(err, "failed to make a composite key for feeConfig")
+
+	feeConfigBytes, err := stub.GetState(feeConfigKey)
+	CheckErr(err, "failed to stub.GetState(feeConfigKey)")
+
+	feeConfig := model.FeeConfig{Exempt: []string{}}
+	if feeConfigBytes == nil {
+		return &feeConfig
+	}
+
+	err = json.Unmarshal(feeConfigBytes, &feeConfig)
+	CheckErr(err, "failed to json.Unmarshal(feeConfigBytes, &feeConfig)")
+
+	return &feeConfig
+}
+
+// putFeeConfig saves the fee config
+func putFeeConfig(stub fabric.Stub, feeConfig *model.FeeConfig) {
+	feeConfigKey, err := stub.CreateCompositeKey("feeConfig", []string{})
+	CheckErr(err, "failed to make a composite key for feeConfig")
+
+	feeConfigBytes, err := json.Marshal(feeConfig)
+	CheckErr(err, "failed to json.Marshal(feeConfig)")
+
+	err = stub.PutState(feeConfigKey, feeConfigBytes)
+	CheckErr(err, "failed to stub.PutState(feeConfigKey, feeConfigBytes)")
+}
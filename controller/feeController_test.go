@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/model"
+	"testing"
+)
+
+func TestSetFeeConfigAsCaller(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+
+	// the owner is the caller, not a param
+	l.mustFail("alice", "the number of params must be two", l.cc.SetFeeConfig, "token", "owner", `{"collector": "alice"}`)
+	l.mustFail("alice", "only the owner can set the fee config", l.cc.SetFeeConfig, "token", `{"collector": "alice"}`)
+	l.mustInvoke("owner", l.cc.SetFeeConfig, "token", `{"flat": 1, "collector": "collector"}`)
+
+	feeConfig := model.FeeConfig{}
+	if err := json.Unmarshal([]byte(l.mustInvoke("", l.cc.GetFeeConfig)), &feeConfig); err != nil {
+		t.Fatal(err)
+	}
+	if feeConfig.Flat != 1 || feeConfig.Collector != "collector" {
+		t.Fatalf("expected the fee config of the owner, got %+v", feeConfig)
+	}
+}
+
+func TestTransferFeeWritesOnce(t *testing.T) {
+	l := newTokenLedger(t, "owner", 10000)
+	l.mustInvoke("owner", l.cc.SetFeeConfig, "token", `{"basisPoints": 100, "collector": "collector", "exempt": ["owner"]}`)
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "1000")
+
+	// the net amount & the fee both change the votes of the sender's delegatee
+	l.mustInvoke("alice", l.cc.Delegate, "alice")
+	l.mustInvoke("bob", l.cc.Delegate, "bob")
+	l.mustInvoke("collector", l.cc.Delegate, "collector")
+
+	l.mustInvoke("alice", l.cc.Transfer, "alice", "bob", "500")
+	transferedEvent := model.TransferedEvent{}
+	if err := json.Unmarshal(l.event.payload, &transferedEvent); err != nil {
+		t.Fatal(err)
+	}
+	if transferedEvent.Fee != "5" {
+		t.Fatalf("expected the fee 5 in the transfer event, got %+v", transferedEvent)
+	}
+	l.expectBalances(map[string]int{"alice": 500, "bob": 495, "collector": 5})
+	expectVotes(l, map[string]string{"alice": "500", "bob": "495", "collector": "5"})
+
+	// the self-transfer pays only the fee
+	l.mustInvoke("alice", l.cc.Transfer, "alice", "alice", "100")
+	l.expectBalances(map[string]int{"alice": 499, "collector": 6})
+	expectVotes(l, map[string]string{"alice": "499", "collector": "6"})
+}
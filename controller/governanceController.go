@@ -0,0 +1,456 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/util"
+	"math/big"
+	"strconv"
+)
+
+// proposalActionArgs is the whitelist of the proposal actions and their number of args
+var proposalActionArgs = map[string]int{
+	"mint":                2, // recipient's address, amount
+	"pause":               0,
+	"unpause":             0,
+	"setGovernanceConfig": 4, // quorum, threshold, voting period, proposal threshold
+	"setFeeConfig":        1, // fee config json
+}
+
+// SetGovernanceConfig is invoke function that sets the governance parameters. /
+// The caller must be the owner of the token /
+// params - tokenName, quorum(minimum votes cast), /
+// threshold(basis points of for votes), voting period(seconds), /
+// proposal threshold(basis points of the total supply held by the proposer).
+func (cc *Controller) SetGovernanceConfig(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is five
+	if len(params) != 5 {
+		return fabric.Error("the number of params must be five")
+	}
+
+	tokenName := params[0]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the caller is the owner of the token
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+	if erc20.Owner != callerAddress {
+		return fabric.Error("only the owner can set the governance config")
+	}
+
+	config, err := convertGovernanceConfig(params[1:])
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+	putGovernanceConfig(stub, config)
+
+	return fabric.Success([]byte("setGovernanceConfig func success"))
+}
+
+// Propose is invoke function that creates a governance proposal of the caller /
+// voted with the balances at the time of the proposal. /
+// The proposal takes a snapshot, so the proposer must hold the proposal threshold /
+// and the proposal keeps the governance config of its creation /
+// params - tokenName, description, action json({"function": "mint", "args": ["recipient", "100"]}).
+// Returns the id of the proposal.
+func (cc *Controller) Propose(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is three
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	tokenName, description, actionJSON := params[0], params[1], params[2]
+
+	proposerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+
+	// check action is whitelisted
+	action := model.ProposalAction{}
+	err = json.Unmarshal([]byte(actionJSON), &action)
+	if err != nil {
+		return fabric.Error("action must be a json of function and args")
+	}
+
+	numArgs, ok := proposalActionArgs[action.Function]
+	if !ok {
+		return fabric.Error("action function is not whitelisted: " + action.Function)
+	}
+	if len(action.Args) != numArgs {
+		return fabric.Error("the number of action args must be " + strconv.Itoa(numArgs))
+	}
+
+	// check the proposer holds the token over the proposal threshold
+	config := getGovernanceConfig(stub)
+	balance, _, err := cc.getStore(stub).GetBalance(proposerAddress)
+	CheckErr(err, "failed to balances.GetBalance(proposerAddress)")
+	if balance == 0 {
+		return fabric.Error("proposer must hold the token")
+	}
+	if compareBasisPoints(balance, int(erc20.TotalSupply), config.ProposalThreshold) < 0 {
+		return fabric.Error("proposer's balance must be over the proposal threshold")
+	}
+
+	// take a snapshot for the voting weight
+	now := getTxUnixTime(stub)
+
+	proposal := model.Proposal{
+		ID:          stub.GetTxID(),
+		TokenName:   tokenName,
+		Proposer:    proposerAddress,
+		Description: description,
+		Action:      action,
+		Config:      config,
+		SnapshotID:  takeSnapshot(stub),
+		Start:       now,
+		End:         now + config.VotingPeriod}
+	putProposal(stub, &proposal)
+
+	return fabric.Success([]byte(proposal.ID))
+}
+
+// CastVote is invoke function that votes on the proposal /
+// with the caller's balance at the time of the proposal /
+// params - proposal id, support(true or false).
+func (cc *Controller) CastVote(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	proposalID := params[0]
+
+	voterAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	support, err := strconv.ParseBool(params[1])
+	if err != nil {
+		return fabric.Error("support must be true or false")
+	}
+
+	proposal := getProposal(stub, proposalID)
+	if proposal == nil {
+		return fabric.Error("proposal does not exist in the ledger")
+	}
+	if getTxUnixTime(stub) >= proposal.End {
+		return fabric.Error("voting period is over")
+	}
+
+	// check the voter did not vote yet
+	voteKey, err := stub.CreateCompositeKey("vote", []string{proposalID, voterAddress})
+	CheckErr(err, "failed to make a composite key for vote")
+
+	voteBytes, err := stub.GetState(voteKey)
+	CheckErr(err, "failed to stub.GetState(voteKey)")
+	if voteBytes != nil {
+		return fabric.Error("voter already voted")
+	}
+
+	// get voting weight
+	balanceResponse := cc.BalanceOfAt(stub, []string{voterAddress, strconv.Itoa(proposal.SnapshotID)})
+	if balanceResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.BalanceOfAt(voterAddress, snapshotID), err: ` + balanceResponse.GetMessage())
+	}
+
+	weight, err := strconv.Atoi(string(balanceResponse.GetPayload()))
+	CheckErr(err, "failed to strconv.Atoi(string(balanceResponse.GetPayload()))")
+	if weight == 0 {
+		return fabric.Error("voter has no voting weight")
+	}
+
+	// save vote
+	vote := model.Vote{ProposalID: proposalID, Voter: voterAddress, Support: support, Weight: weight}
+	voteBytes, err = json.Marshal(vote)
+	CheckErr(err, "failed to json.Marshal(vote)")
+
+	err = stub.PutState(voteKey, voteBytes)
+	CheckErr(err, "failed to stub.PutState(voteKey, voteBytes)")
+
+	if support {
+		proposal.ForVotes += weight
+	} else {
+		proposal.AgainstVotes += weight
+	}
+	putProposal(stub, proposal)
+
+	// emit vote event
+	err = stub.SetEvent("voteEvent", voteBytes)
+	CheckErr(err, `failed to stub.SetEvent("voteEvent", voteBytes)`)
+
+	return fabric.Success([]byte("castVote func success"))
+}
+
+// Execute is invoke function that applies the action of the passed proposal /
+// after the voting period /
+// params - proposal id.
+func (cc *Controller) Execute(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	proposal := getProposal(stub, params[0])
+	if proposal == nil {
+		return fabric.Error("proposal does not exist in the ledger")
+	}
+	if proposal.Executed {
+		return fabric.Error("proposal is already executed")
+	}
+	if getTxUnixTime(stub) < proposal.End {
+		return fabric.Error("voting period is not over")
+	}
+
+	// check quorum & threshold of the config at the proposal, /
+	// the proposals created before the config was recorded use the current config
+	config := proposal.Config
+	if config == nil {
+		config = getGovernanceConfig(stub)
+	}
+	totalVotes := proposal.ForVotes + proposal.AgainstVotes
+	if totalVotes == 0 || totalVotes < config.Quorum {
+		return fabric.Error("proposal did not reach the quorum")
+	}
+	if compareBasisPoints(proposal.ForVotes, totalVotes, config.Threshold) <= 0 {
+		return fabric.Error("proposal did not pass the threshold")
+	}
+
+	// apply action
+	erc20 := cc.getMetadata(stub, proposal.TokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+
+	args := proposal.Action.Args
+	switch proposal.Action.Function {
+	case "mint":
+		mintResponse := cc.mint(stub, erc20, args[0], args[1])
+		if mintResponse.Status >= 400 {
+			return fabric.Error(`failed to cc.mint(erc20, recipient, amount), err: ` + mintResponse.GetMessage())
+		}
+	case "pause":
+		setPaused(stub, true)
+	case "unpause":
+		setPaused(stub, false)
+	case "setGovernanceConfig":
+		newConfig, err := convertGovernanceConfig(args)
+		if err != nil {
+			return fabric.Error(err.Error())
+		}
+		putGovernanceConfig(stub, newConfig)
+	case "setFeeConfig":
+		feeConfig, err := convertFeeConfig(args[0])
+		if err != nil {
+			return fabric.Error(err.Error())
+		}
+		putFeeConfig(stub, feeConfig)
+	default:
+		return fabric.Error("action function is not whitelisted: " + proposal.Action.Function)
+	}
+
+	proposal.Executed = true
+	putProposal(stub, proposal)
+
+	return fabric.Success([]byte("execute func success"))
+}
+
+// GetProposal is query function
+// params - proposal id
+// Returns the proposal.
+func (cc *Controller) GetProposal(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	proposal := getProposal(stub, params[0])
+	if proposal == nil {
+		return fabric.Error("proposal does not exist in the ledger")
+	}
+
+	proposalBytes, err := json.Marshal(proposal)
+	CheckErr(err, "failed to json.Marshal(proposal)")
+
+	return fabric.Success(proposalBytes)
+}
+
+// ProposalList is query function
+// params - page size, bookmark(empty for the first page)
+// Returns the page of the proposals and the bookmark of the next page.
+func (cc *Controller) ProposalList(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	pageSize, err := util.ConverToPositive(params[0], "pageSize")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	keyValues, bookmark := getPage(stub, "proposal", []string{}, pageSize, params[1])
+
+	proposalPage := model.ProposalPage{Proposals: []model.Proposal{}, Bookmark: bookmark}
+	for _, keyValue := range keyValues {
+		proposal := model.Proposal{}
+		err = json.Unmarshal(keyValue.GetValue(), &proposal)
+		CheckErr(err, "failed to json.Unmarshal(proposalBytes, &proposal)")
+
+		proposalPage.Proposals = append(proposalPage.Proposals, proposal)
+	}
+
+	proposalPageBytes, err := json.Marshal(proposalPage)
+	CheckErr(err, "failed to json.Marshal(proposalPage)")
+
+	return fabric.Success(proposalPageBytes)
+}
+
+// VoteList is query function
+// params - proposal id, page size, bookmark(empty for the first page)
+// Returns the page of the votes on the proposal and the bookmark of the next page.
+func (cc *Controller) VoteList(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	proposalID := params[0]
+
+	pageSize, err := util.ConverToPositive(params[1], "pageSize")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	keyValues, bookmark := getPage(stub, "vote", []string{proposalID}, pageSize, params[2])
+
+	votePage := model.VotePage{Votes: []model.Vote{}, Bookmark: bookmark}
+	for _, keyValue := range keyValues {
+		vote := model.Vote{}
+		err = json.Unmarshal(keyValue.GetValue(), &vote)
+		CheckErr(err, "failed to json.Unmarshal(voteBytes, &vote)")
+
+		votePage.Votes = append(votePage.Votes, vote)
+	}
+
+	votePageBytes, err := json.Marshal(votePage)
+	CheckErr(err, "failed to json.Marshal(votePage)")
+
+	return fabric.Success(votePageBytes)
+}
+
+// compareBasisPoints compares value with the basis points of total, /
+// -1 if value is under, 0 if equal and +1 if over
+func compareBasisPoints(value, total, basisPoints int) int {
+	valueBasisPoints := new(big.Int).Mul(big.NewInt(int64(value)), big.NewInt(10000))
+	totalBasisPoints := new(big.Int).Mul(big.NewInt(int64(total)), big.NewInt(int64(basisPoints)))
+
+	return valueBasisPoints.Cmp(totalBasisPoints)
+}
+
+// convertGovernanceConfig converts quorum, threshold, voting period, proposal threshold
+func convertGovernanceConfig(values []string) (*model.GovernanceConfig, error) {
+	quorum, err := strconv.Atoi(values[0])
+	if err != nil || quorum < 0 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "quorum",
+			Message:    "must be integer and cannot be negative"}
+	}
+
+	threshold, err := strconv.Atoi(values[1])
+	if err != nil || threshold < 0 || threshold >= 10000 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "threshold",
+			Message:    "must be basis points between 0 and 9999"}
+	}
+
+	votingPeriod, err := strconv.ParseInt(values[2], 10, 64)
+	if err != nil || votingPeriod <= 0 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "votingPeriod",
+			Message:    "must be more than zero"}
+	}
+
+	proposalThreshold, err := strconv.Atoi(values[3])
+	if err != nil || proposalThreshold < 0 || proposalThreshold > 10000 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "proposalThreshold",
+			Message:    "must be basis points between 0 and 10000"}
+	}
+
+	return &model.GovernanceConfig{Quorum: quorum, Threshold: threshold, VotingPeriod: votingPeriod, ProposalThreshold: proposalThreshold}, nil
+}
+
+// getGovernanceConfig gets the governance parameters, the default if not set
+func getGovernanceConfig(stub fabric.Stub) *model.GovernanceConfig {
+	configKey, err := stub.CreateCompositeKey("governanceConfig", []string{})
+	CheckErr(err, "failed to make a composite key for governanceConfig")
+
+	configBytes, err := stub.GetState(configKey)
+	CheckErr(err, "failed to stub.GetState(configKey)")
+	if configBytes == nil {
+		return model.NewGovernanceConfig()
+	}
+
+	config := model.GovernanceConfig{}
+	err = json.Unmarshal(configBytes, &config)
+	CheckErr(err, "failed to json.Unmarshal(configBytes, &config)")
+
+	return &config
+}
+
+// putGovernanceConfig saves the governance parameters
+func putGovernanceConfig(stub fabric.Stub, config *model.GovernanceConfig) {
+	configKey, err := stub.CreateCompositeKey("governanceConfig", []string{})
+	CheckErr(err, "failed to make a composite key for governanceConfig")
+
+	configBytes, err := json.Marshal(config)
+	CheckErr(err, "failed to json.Marshal(config)")
+
+	err = stub.PutState(configKey, configBytes)
+	CheckErr(err, "failed to stub.PutState(configKey, configBytes)")
+}
+
+// getProposal gets the proposal from the ledger, nil if it does not exist
+func getProposal(stub fabric.Stub, proposalID string) *model.Proposal {
+	proposalKey, err := stub.CreateCompositeKey("proposal", []string{proposalID})
+	CheckErr(err, "failed to make a composite key for proposal")
+
+	proposalBytes, err := stub.GetState(proposalKey)
+	CheckErr(err, "failed to stub.GetState(proposalKey)")
+	if proposalBytes == nil {
+		return nil
+	}
+
+	proposal := model.Proposal{}
+	err = json.Unmarshal(proposalBytes, &proposal)
+	CheckErr(err, "failed to json.Unmarshal(proposalBytes, &proposal)")
+
+	return &proposal
+}
+
+// putProposal saves the proposal to the ledger
+func putProposal(stub fabric.Stub, proposal *model.Proposal) {
+	proposalKey, err := stub.CreateCompositeKey("proposal", []string{proposal.ID})
+	CheckErr(err, "failed to make a composite key for proposal")
+
+	proposalBytes, err := json.Marshal(proposal)
+	CheckErr(err, "failed to json.Marshal(proposal)")
+
+	err = stub.PutState(proposalKey, proposalBytes)
+	CheckErr(err, "failed to stub.PutState(proposalKey, proposalBytes)")
+}
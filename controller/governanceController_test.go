@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/model"
+	"testing"
+)
+
+// newGovernanceLedger sets the quorum 100, the threshold 50%, the voting period of an hour /
+// and the proposal threshold 1%, the owner holds 600 and alice 400
+func newGovernanceLedger(t *testing.T) *testLedger {
+	l := newTokenLedger(t, "owner", 1000)
+	l.mustInvoke("owner", l.cc.SetGovernanceConfig, "token", "100", "5000", "3600", "100")
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "400")
+
+	return l
+}
+
+func TestSetGovernanceConfigAsCaller(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+
+	// the owner is the caller, not a param
+	l.mustFail("alice", "only the owner can set the governance config", l.cc.SetGovernanceConfig, "token", "1", "0", "60", "0")
+	l.mustFail("alice", "the number of params must be five", l.cc.SetGovernanceConfig, "token", "owner", "1", "0", "60", "0")
+	l.mustFail("owner", "threshold", l.cc.SetGovernanceConfig, "token", "1", "10000", "60", "0")
+	l.mustFail("owner", "proposalThreshold", l.cc.SetGovernanceConfig, "token", "1", "0", "60", "10001")
+	l.mustInvoke("owner", l.cc.SetGovernanceConfig, "token", "1", "0", "60", "0")
+
+	proposalID := l.mustInvoke("owner", l.cc.Propose, "token", "pause", `{"function": "pause", "args": []}`)
+
+	proposal := model.Proposal{}
+	json.Unmarshal([]byte(l.mustInvoke("", l.cc.GetProposal, proposalID)), &proposal)
+	if proposal.End-proposal.Start != 60 {
+		t.Fatalf("expected the voting period of the config, got %+v", proposal)
+	}
+}
+
+func TestProposeAndVoteAsCaller(t *testing.T) {
+	l := newGovernanceLedger(t)
+
+	// the proposer is the caller, not a param
+	l.mustFail("mallory", "proposer must hold the token", l.cc.Propose, "token", "pause", `{"function": "pause", "args": []}`)
+	l.mustFail("mallory", "the number of params must be three", l.cc.Propose, "token", "alice", "pause", `{"function": "pause", "args": []}`)
+	l.mustFail("alice", "action function is not whitelisted", l.cc.Propose, "token", "burn", `{"function": "burn", "args": []}`)
+	proposalID := l.mustInvoke("alice", l.cc.Propose, "token", "mint to alice", `{"function": "mint", "args": ["alice", "100"]}`)
+
+	proposal := model.Proposal{}
+	json.Unmarshal([]byte(l.mustInvoke("", l.cc.GetProposal, proposalID)), &proposal)
+	if proposal.Proposer != "alice" {
+		t.Fatalf("expected the proposal of the caller, got %+v", proposal)
+	}
+
+	// the voter is the caller, not a param
+	l.mustFail("mallory", "the number of params must be two", l.cc.CastVote, "alice", proposalID, "true")
+	l.mustFail("mallory", "voter has no voting weight", l.cc.CastVote, proposalID, "true")
+	l.mustInvoke("alice", l.cc.CastVote, proposalID, "true")
+	l.mustFail("alice", "voter already voted", l.cc.CastVote, proposalID, "false")
+
+	// the balance after the proposal does not vote
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "bob", "100")
+	l.mustFail("bob", "voter has no voting weight", l.cc.CastVote, proposalID, "true")
+	l.mustInvoke("owner", l.cc.CastVote, proposalID, "false")
+
+	json.Unmarshal([]byte(l.mustInvoke("", l.cc.GetProposal, proposalID)), &proposal)
+	if proposal.ForVotes != 400 || proposal.AgainstVotes != 600 {
+		t.Fatalf("expected 400 for and 600 against, got %+v", proposal)
+	}
+
+	l.mustFail("", "voting period is not over", l.cc.Execute, proposalID)
+	l.now += 3600
+	l.mustFail("alice", "voting period is over", l.cc.CastVote, proposalID, "true")
+	l.mustFail("", "proposal did not pass the threshold", l.cc.Execute, proposalID)
+}
+
+func TestExecutePassedProposal(t *testing.T) {
+	l := newGovernanceLedger(t)
+	proposalID := l.mustInvoke("alice", l.cc.Propose, "token", "mint to alice", `{"function": "mint", "args": ["alice", "100"]}`)
+	l.mustInvoke("owner", l.cc.CastVote, proposalID, "true")
+
+	l.now += 3600
+	l.mustInvoke("", l.cc.Execute, proposalID)
+	l.mustFail("", "proposal is already executed", l.cc.Execute, proposalID)
+
+	l.expectBalances(map[string]int{"owner": 600, "alice": 500})
+	if supply := l.mustInvoke("", l.cc.TotalSupply, "token"); supply != "1100" {
+		t.Fatalf("expected the total supply 1100, got %s", supply)
+	}
+}
+
+func TestProposalThreshold(t *testing.T) {
+	l := newGovernanceLedger(t)
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "bob", "9")
+
+	// each proposal takes a snapshot, the small holders cannot propose
+	l.mustFail("bob", "proposer's balance must be over the proposal threshold", l.cc.Propose, "token", "pause", `{"function": "pause", "args": []}`)
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "bob", "1")
+	l.mustInvoke("bob", l.cc.Propose, "token", "pause", `{"function": "pause", "args": []}`)
+}
+
+func TestExecuteWithTheConfigOfTheProposal(t *testing.T) {
+	l := newGovernanceLedger(t)
+	proposalID := l.mustInvoke("alice", l.cc.Propose, "token", "pause", `{"function": "pause", "args": []}`)
+	l.mustInvoke("alice", l.cc.CastVote, proposalID, "true")
+
+	// the quorum raised after the proposal does not apply to it
+	l.mustInvoke("owner", l.cc.SetGovernanceConfig, "token", "1000", "5000", "3600", "100")
+
+	l.now += 3600
+	l.mustInvoke("", l.cc.Execute, proposalID)
+	if paused := l.mustInvoke("", l.cc.Paused); paused != "true" {
+		t.Fatalf("expected the token paused, got %s", paused)
+	}
+}
+
+func TestCompareBasisPoints(t *testing.T) {
+	for _, test := range []struct {
+		value, total, basisPoints, expected int
+	}{
+		{5000, 10000, 5000, 0},
+		{5001, 10000, 5000, 1},
+		{1, 100, 101, -1},
+		{maxAmount, maxAmount, 9999, 1},
+		{maxAmount - 1, maxAmount, 10000, -1},
+	} {
+		if result := compareBasisPoints(test.value, test.total, test.basisPoints); result != test.expected {
+			t.Errorf("compareBasisPoints(%d, %d, %d): expected %d, got %d", test.value, test.total, test.basisPoints, test.expected, result)
+		}
+	}
+}
@@ -3,220 +3,290 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"hypherledgertest2/fabric"
 	"hypherledgertest2/model"
+	"hypherledgertest2/store"
 	"hypherledgertest2/util"
 	"strconv"
-
-	"github.com/hyperledger/fabric/core/chaincode/shim"
-	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
+// escrowAddresses are the accounts holding the tokens escrowed by the chaincode
+var escrowAddresses = []string{VestingEscrowAddress, DistributionEscrowAddress, PrivateEscrowAddress}
+
 // Transfer is invoke function that moves amount token /
 // from the caller's address to recipient /
 // params - caller's address, recipient's address, amount of token.
-func (cc *Controller) Transfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) Transfer(stub fabric.Stub, params []string) fabric.Response {
 	// check a number of params is 3
 	if len(params) != 3 {
-		return shim.Error("the number of params must be three")
+		return fabric.Error("the number of params must be three")
+	}
+
+	// escrow accounts are moved only by the functions holding the escrowed tokens
+	if isEscrowAddress(params[0]) {
+		return fabric.Error("escrow account cannot transfer")
+	}
+
+	// multisig accounts are moved only by the confirmations of the signers
+	if getMultisig(stub, params[0]) != nil {
+		return fabric.Error("multisig account can only transfer with the confirmations of the signers")
 	}
 
+	return cc.transfer(stub, params)
+}
+
+// transfer moves amount token from the caller's address to recipient and emits the transfer event
+func (cc *Controller) transfer(stub fabric.Stub, params []string) fabric.Response {
 	callerAddress, recipientAddress, transferedMoney := params[0], params[1], params[2]
 
+	transferedEvent, response := cc.moveTokens(stub, callerAddress, recipientAddress, transferedMoney)
+	if response.Status >= 400 {
+		return response
+	}
+
+	// emit transfer event
+	transferedEventBytes, err := json.Marshal(transferedEvent)
+	CheckErr(err, "failed to json.Marshal(transferedEvent)")
+
+	err = stub.SetEvent("transferEvent", transferedEventBytes)
+	CheckErr(err, `failed to stub.SetEvent("transferEvent", transferedEventBytes)`)
+
+	fmt.Println(callerAddress + `sent ` + transferedMoney + ` to ` + recipientAddress)
+
+	return response
+}
+
+// moveTokens moves amount token from the caller's address to recipient without emitting the event, /
+// so that the private transfers do not publish their amount.
+// Returns the transfer event of the move.
+func (cc *Controller) moveTokens(stub fabric.Stub, callerAddress, recipientAddress, transferedMoney string) (*model.TransferedEvent, fabric.Response) {
+	// check the token is not paused
+	if isPaused(stub) {
+		return nil, fabric.Error("token transfers are paused")
+	}
+
 	// check amount is integer & positive
 	transferedMoneyInt, err := util.ConverToPositive(transferedMoney, "transferedMoney")
 	if err != nil {
-		return shim.Error(err.Error())
+		return nil, fabric.Error(err.Error())
 	}
 
+	balances := cc.getStore(stub)
+
 	// get caller amount
-	callerAmountBytes, err := stub.GetState(callerAddress)
-	CheckErr(err, "failed to stub.GetState(callerAddress)")
-	if callerAmountBytes == nil {
-		return shim.Error("callerAmountBytes does not exist in the DB")
+	callerAmountInt, exists, err := balances.GetBalance(callerAddress)
+	CheckErr(err, "failed to balances.GetBalance(callerAddress)")
+	if !exists {
+		return nil, fabric.Error("caller's balance does not exist in the DB")
 	}
 
-	callerAmountInt, err := strconv.Atoi(string(callerAmountBytes))
-	CheckErr(err, "failed to strconv.Atoi(string(callerAmountBytes))")
-
 	// check callerReuslt transferedResult is positive
 	if callerAmountInt < transferedMoneyInt {
-		return shim.Error("caller's amount must be over the transfered money")
+		return nil, fabric.Error("caller's amount must be over the transfered money")
 	}
 
-	// get recipient amount
-	recipientAmountBytes, err := stub.GetState(recipientAddress)
-	CheckErr(err, "failed to stub.GetState(recipientAddress)")
-	if recipientAmountBytes == nil {
-		recipientAmountBytes = []byte("0")
+	// check & record the caller's spending limits
+	limitResponse := spendOutflow(stub, callerAddress, transferedMoneyInt)
+	if limitResponse.Status >= 400 {
+		return nil, limitResponse
 	}
 
-	recipientAmountInt, err := strconv.Atoi(string(recipientAmountBytes))
-	CheckErr(err, "failed to strconv.Atoi(string(recipientAmountBytes))")
-
-	// calculate amount
-	callerResult := callerAmountInt - transferedMoneyInt
-	recipientResult := recipientAmountInt + transferedMoneyInt
-
-	// save the caller's & recipient's amount
-	callerResultBytes, err := json.Marshal(callerResult)
-	CheckErr(err, "failed to json.Marshal(callerResult)")
-
-	err = stub.PutState(callerAddress, callerResultBytes)
-	CheckErr(err, "failed to stub.PutState(callerAddress, callerResultBytes)")
+	// calculate transfer fee, paid from the transfered money
+	fee, collectorAddress := transferFee(stub, callerAddress, recipientAddress, transferedMoneyInt)
+	if fee > transferedMoneyInt {
+		return nil, fabric.Error("transfered money must be over the fee")
+	}
 
-	recipientResultBytes, err := json.Marshal(recipientResult)
-	CheckErr(err, "failed to json.Marshal(recipientResult)")
+	// sum the changes of each address, see applyBalanceChanges
+	balanceChanges := map[string]int{callerAddress: -transferedMoneyInt}
+	balanceChanges[recipientAddress] += transferedMoneyInt - fee
+	if fee > 0 {
+		balanceChanges[collectorAddress] += fee
+	}
 
-	err = stub.PutState(recipientAddress, recipientResultBytes)
-	CheckErr(err, "failed to stub.PutState(recipientAddress, recipientResultBytes)")
+	// save the amounts & move voting power between the delegatees
+	applyBalanceChanges(stub, balances, balanceChanges)
+	moveVotingPowers(stub, balanceChanges)
 
-	// emit transfer event
 	transferedEvent := model.TransferedEvent{
 		Sender:          callerAddress,
 		Recipient:       recipientAddress,
 		TransferedMoney: transferedMoney}
+	if fee > 0 {
+		transferedEvent.Fee = strconv.Itoa(fee)
+	}
 
-	transferedEventBytes, err := json.Marshal(transferedEvent)
-	CheckErr(err, "failed to json.Marshal(transferedEvent)")
+	return &transferedEvent, fabric.Success([]byte("Transfer Success"))
+}
 
-	err = stub.SetEvent("transferEvent", transferedEventBytes)
-	CheckErr(err, `failed to stub.SetEvent("transferEvent", transferedEventBytes)`)
+// applyBalanceChanges adds the summed change to the balance of each address, /
+// reading & writing each balance once: a peer does not return the writes of the transaction to its reads, /
+// so a second write of the same key would overwrite the first with a stale balance. /
+// Records the balances before the change for the current snapshot.
+func applyBalanceChanges(stub fabric.Stub, balances store.BalanceStore, balanceChanges map[string]int) {
+	for _, address := range sortedAddresses(balanceChanges) {
+		if balanceChanges[address] == 0 {
+			continue
+		}
 
-	fmt.Println(callerAddress + `sent ` + transferedMoney + ` to ` + recipientAddress)
+		amountInt, err := credit(balances, address, balanceChanges[address])
+		CheckErr(err, "failed to credit(balances, address, balanceChanges[address])")
 
-	return shim.Success([]byte("Transfer Success"))
+		updateBalanceSnapshot(stub, address, amountInt)
+	}
+}
+
+// isEscrowAddress checks the address is one of the escrow accounts of the chaincode
+func isEscrowAddress(address string) bool {
+	for _, escrowAddress := range escrowAddresses {
+		if address == escrowAddress {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Approve is invoke function that Sets amount as the allowance /
 // of spender over the owner tokens /
 // params - owner's address, spender's address, amount of token.
-func (cc *Controller) Approve(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) Approve(stub fabric.Stub, params []string) fabric.Response {
 	// check the number of params is three
 	if len(params) != 3 {
-		return shim.Error("the number of params must be three")
+		return fabric.Error("the number of params must be three")
 	}
 
-	ownerAddress, spenderAddress, amount := params[0], params[1], params[2]
+	return cc.approve(stub, params, 0)
+}
 
-	// check amount is integer & positive
-	amountInt, err := util.ConverToPositive(amount, "approveAmount")
+// ApproveWithExpiry is invoke function that Sets amount as the allowance /
+// of spender over the owner tokens until the expiry /
+// params - owner's address, spender's address, amount of token, expiresAt(unix seconds).
+func (cc *Controller) ApproveWithExpiry(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is four
+	if len(params) != 4 {
+		return fabric.Error("the number of params must be four")
+	}
+
+	// check expiresAt is in the future
+	expiresAt, err := strconv.ParseInt(params[3], 10, 64)
 	if err != nil {
-		return shim.Error(err.Error())
+		return fabric.Error("expiresAt must be a unix timestamp")
+	}
+	if expiresAt <= getTxUnixTime(stub) {
+		return fabric.Error("expiresAt must be in the future")
+	}
+
+	return cc.approve(stub, params[:3], expiresAt)
+}
+
+// approve sets amount as the allowance of spender over the owner tokens, /
+// expiresAt 0 means no expiry
+func (cc *Controller) approve(stub fabric.Stub, params []string, expiresAt int64) fabric.Response {
+	ownerAddress, spenderAddress, amount := params[0], params[1], params[2]
+
+	// escrow & multisig accounts cannot approve
+	if isEscrowAddress(ownerAddress) {
+		return fabric.Error("escrow account cannot approve")
+	}
+	if getMultisig(stub, ownerAddress) != nil {
+		return fabric.Error("multisig account cannot approve")
 	}
 
-	// create composite key for allowance: approval/owner/spender
-	approvalKey, err := stub.CreateCompositeKey("approval", []string{ownerAddress, spenderAddress})
-	CheckErr(err, "failed to make a composit key for approval")
+	// check amount is integer & not negative, zero resets the allowance
+	amountInt, err := util.ConvertToNonNegative(amount, "approveAmount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
 
-	// save the allowance amount
-	err = stub.PutState(approvalKey, []byte(amount))
-	CheckErr(err, "failed to stub.PutState(approvalKey, []byte(amount))")
+	// save the allowance record: approval/owner/spender
+	cc.putAllowance(stub, ownerAddress, spenderAddress, &model.Allowance{Amount: amountInt, ExpiresAt: expiresAt})
 
 	// emit approval event
-	approvalEvent := model.ApprovalEvent{Owner: ownerAddress, Spender: spenderAddress, Amount: amountInt}
+	approvalEvent := model.ApprovalEvent{Owner: ownerAddress, Spender: spenderAddress, Amount: amountInt, ExpiresAt: expiresAt}
 	approvalEventByte, err := json.Marshal(approvalEvent)
 	CheckErr(err, "failed to json.Marshal(approvalEvent)")
 
 	err = stub.SetEvent("approvalEvent", approvalEventByte)
 
-	return shim.Success([]byte("allowance success"))
+	return fabric.Success([]byte("allowance success"))
 }
 
 // TransferFrom is a invoke function that Moves amount of tokens from sender(owner) to recipient /
-// using allowance of spender /
-// parmas - owner's address, spender's address, recipient's address, amount of token.
-func (cc *Controller) TransferFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	// check the number of parmas is 4
-	if len(params) != 4 {
-		return shim.Error("the number of params must be four")
+// using allowance of spender, who is the caller of the transaction. /
+// The spender's allowance is decreased by amount unless it is InfiniteAllowance /
+// parmas - owner's address, recipient's address, amount of token.
+func (cc *Controller) TransferFrom(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of parmas is 3
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
 	}
 
-	ownerAddress, spenderAddress, recipientAddress, amount := params[0], params[1], params[2], params[3]
+	ownerAddress, recipientAddress, amount := params[0], params[1], params[2]
 
 	// check amount is integer & positive
 	amountInt, err := util.ConverToPositive(amount, "TransferedAmount")
 	if err != nil {
-		return shim.Error(err.Error())
+		return fabric.Error(err.Error())
 	}
 
-	spenderAllowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
-	if spenderAllowanceResponse.Status >= 400 {
-		return shim.Error(`failed to cc.allowance([]string{ownerAddress, spenderAddress}), err: ` + spenderAllowanceResponse.GetMessage())
+	// the spender is the caller
+	spenderAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
 	}
 
-	// get allowance of spender and recipient
-	recipientAllowanceResponse := cc.Allowance(stub, []string{ownerAddress, recipientAddress})
-	if recipientAllowanceResponse.Status >= 400 {
-		return shim.Error(`failed to cc.allowance([]string{ownerAddress, spenderAddress}), err: ` + recipientAllowanceResponse.GetMessage())
+	// check the allowance of spender covers amount, zero if expired
+	allowance := cc.getAllowance(stub, ownerAddress, spenderAddress)
+	allowanceInt := allowanceAmount(stub, allowance)
+	if allowanceInt < amountInt {
+		return fabric.Error("spender's allowance must be over the transfered money")
 	}
 
-	// convert allowance response paylaod to allowance data(int)
-	spenderAllowanceStr := string(spenderAllowanceResponse.GetPayload())
-	spenderAllowanceInt, err := strconv.Atoi(spenderAllowanceStr)
-	CheckErr(err, `failed to strconv.Atoi(spenderAllowanceStr)`)
-
-	recipientAllowanceStr := string(recipientAllowanceResponse.GetPayload())
-	recipientAllowanceInt, err := strconv.Atoi(recipientAllowanceStr)
-	CheckErr(err, `failed to strconv.Atoi(recipientAllowanceStr)`)
-
 	// transfer from owner to recipient
 	transferResponse := cc.Transfer(stub, []string{ownerAddress, recipientAddress, amount})
-	if transferResponse.Status >= 400 {
-		return shim.Error(`failed to cc.transfer([]string{spenderAddress, recipientAddress, amount}), err: ` + transferResponse.GetMessage())
+	if transferResponse.Status == SpendingLimitExceededStatus {
+		return transferResponse
 	}
-
-	// decrease & increase allowance amount
-	spenderAllowanceInt -= amountInt
-	recipientAllowanceInt += amountInt
-
-	// approve amount of tokens transfered
-	spenderAllowanceStr = strconv.Itoa(spenderAllowanceInt)
-	recipientAllowanceStr = strconv.Itoa(recipientAllowanceInt)
-
-	Res := cc.Approve(stub, []string{ownerAddress, spenderAddress, spenderAllowanceStr})
-	if Res.Status >= 400 {
-		return shim.Error(`failed to cc.approve(ownerAddress, spenderAddress, spenderAllowanceStr), err: ` + Res.GetMessage())
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.transfer([]string{ownerAddress, recipientAddress, amount}), err: ` + transferResponse.GetMessage())
 	}
 
-	Res = cc.Approve(stub, []string{ownerAddress, recipientAddress, recipientAllowanceStr})
-	if Res.Status >= 400 {
-		return shim.Error(`failed to cc.approve(ownerAddress, recipientAddress, recipientAllowanceStr), err: ` + Res.GetMessage())
+	// decrease spender's allowance
+	err = spendAllowance(cc.getStore(stub), ownerAddress, spenderAddress, amountInt, getTxUnixTime(stub))
+	if err != nil {
+		return fabric.Error(err.Error())
 	}
 
-	return shim.Success([]byte("transferFrom func success"))
+	return fabric.Success([]byte("transferFrom func success"))
 }
 
 // TransferFromOther is an invoke function that invokes transferFrom in different chaincode /
-// params - chaincodeName, senderAddress, recipientAddress, amount
-func (cc *Controller) TransferFromOther(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+// with the allowance of the caller in that chaincode /
+// params - chaincodeName, ownerAddress, recipientAddress, amount
+func (cc *Controller) TransferFromOther(stub fabric.Stub, params []string) fabric.Response {
 	// check the number of parmas is 4
-	if len(params) != 5 {
-		return shim.Error("the number of params must be five")
+	if len(params) != 4 {
+		return fabric.Error("the number of params must be four")
 	}
 
-	chaincodeName, ownerAddress, senderAddress, recipientAddress, amount := params[0], params[1], params[2], params[3], params[4]
-
-	// make arguments
-	args := [][]byte{[]byte("transferFrom"), []byte(ownerAddress), []byte(senderAddress), []byte(recipientAddress), []byte(amount)}
-
-	// get channel
-	channelID := stub.GetChannelID()
+	chaincodeName, ownerAddress, recipientAddress, amount := params[0], params[1], params[2], params[3]
 
 	// invoke transferFrom in another chaincode
-	invokeResponse := stub.InvokeChaincode(chaincodeName, args, channelID)
+	invokeResponse := invokeChaincode(stub, chaincodeName, "transferFrom", ownerAddress, recipientAddress, amount)
 	if invokeResponse.GetStatus() >= 400 {
-		return shim.Error(`failed to stub.InvokeChaincode(chaincodeName, args, channelID), err: ` + invokeResponse.GetMessage())
+		return fabric.Error(`failed to stub.InvokeChaincode(chaincodeName, args, channelID), err: ` + invokeResponse.GetMessage())
 	}
 
-	return shim.Success([]byte("transferFrom in other token success"))
+	return fabric.Success([]byte("transferFrom in other token success"))
 }
 
 // IncreaseAllowance is invoke function that increases spender's allowance by owner /
 // params - owner's address, spender's address, amount of increase.
-func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) IncreaseAllowance(stub fabric.Stub, params []string) fabric.Response {
 	// check the number of params if three
 	if len(params) != 3 {
-		return shim.Error("the number of params must be three")
+		return fabric.Error("the number of params must be three")
 	}
 
 	ownerAddress, targetAddress, amount := params[0], params[1], params[2]
@@ -224,38 +294,35 @@ func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params
 	// check amount is integer & positive
 	amountInt, err := util.ConverToPositive(amount, "IncreaseAmount")
 	if err != nil {
-		return shim.Error(err.Error())
+		return fabric.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, targetAddress})
-	if allowanceResponse.Status >= 400 {
-		return shim.Error(`failed to get allowanceResponse, err: ` + allowanceResponse.GetMessage())
+	// get allowance, an expired allowance must be approved again
+	allowance := cc.getAllowance(stub, ownerAddress, targetAddress)
+	if isAllowanceExpired(stub, allowance) {
+		return fabric.Error("allowance is expired, use approve or approveWithExpiry")
 	}
-
-	// convert allowance response payload to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
-	CheckErr(err, `failed to strconv.Atoi(string(allowanceResponse.GetPayload()))`)
+	allowanceInt := allowance.Amount
 
 	// increase allowance
 	allowanceInt += amountInt
 
-	// call approve
+	// call approve, keeping the expiry
 	allowanceStr := strconv.Itoa(allowanceInt)
-	approveResponse := cc.Approve(stub, []string{ownerAddress, targetAddress, allowanceStr})
+	approveResponse := cc.approve(stub, []string{ownerAddress, targetAddress, allowanceStr}, allowance.ExpiresAt)
 	if approveResponse.Status >= 400 {
-		return shim.Error(`failed to get approveResponse, err: ` + approveResponse.GetMessage())
+		return fabric.Error(`failed to get approveResponse, err: ` + approveResponse.GetMessage())
 	}
 
-	return shim.Success([]byte("increaseAllowance func success"))
+	return fabric.Success([]byte("increaseAllowance func success"))
 }
 
 // DecreaseAllowance is invoke function that increases spender's allowance by owner /
 // params - owner's address, spender's address, amount of decrease.
-func (cc *Controller) DecreaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) DecreaseAllowance(stub fabric.Stub, params []string) fabric.Response {
 	// check the number of params if three
 	if len(params) != 3 {
-		return shim.Error("the number of params must be three")
+		return fabric.Error("the number of params must be three")
 	}
 
 	ownerAddress, targetAddress, amount := params[0], params[1], params[2]
@@ -263,38 +330,190 @@ func (cc *Controller) DecreaseAllowance(stub shim.ChaincodeStubInterface, params
 	// check amount is integer & positive
 	amountInt, err := util.ConverToPositive(amount, "descreaseAmount")
 	if err != nil {
-		return shim.Error(err.Error())
+		return fabric.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, targetAddress})
-	if allowanceResponse.Status >= 400 {
-		return shim.Error(`failed to get allowanceResponse, err: ` + allowanceResponse.GetMessage())
+	// get allowance, an expired allowance must be approved again
+	allowance := cc.getAllowance(stub, ownerAddress, targetAddress)
+	if isAllowanceExpired(stub, allowance) {
+		return fabric.Error("allowance is expired, use approve or approveWithExpiry")
 	}
-
-	// convert allowance response payload to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
-	CheckErr(err, `failed to strconv.Atoi(string(allowanceResponse.GetPayload()))`)
+	allowanceInt := allowance.Amount
 
 	// decrease allowance
 	allowanceInt -= amountInt
 
-	// call approve
+	// call approve, keeping the expiry
 	allowanceStr := strconv.Itoa(allowanceInt)
-	approveResponse := cc.Approve(stub, []string{ownerAddress, targetAddress, allowanceStr})
+	approveResponse := cc.approve(stub, []string{ownerAddress, targetAddress, allowanceStr}, allowance.ExpiresAt)
 	if approveResponse.Status >= 400 {
-		return shim.Error(`failed to get approveResponse, err: ` + approveResponse.GetMessage())
+		return fabric.Error(`failed to get approveResponse, err: ` + approveResponse.GetMessage())
 	}
 
-	return shim.Success([]byte("decreaseAllowance func success"))
+	return fabric.Success([]byte("decreaseAllowance func success"))
 }
 
-// Mint is ...
-func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	return shim.Success(nil)
+// Mint is invoke function that creates amount token /
+// and assigns them to the recipient, increasing the total supply. /
+// The caller must be the owner of the token /
+// params - tokenName, recipient's address, amount of token.
+func (cc *Controller) Mint(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is three
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	tokenName, recipientAddress, amount := params[0], params[1], params[2]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the caller is the owner of the token
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+	if erc20.Owner != callerAddress {
+		return fabric.Error("only the owner can mint")
+	}
+
+	return cc.mint(stub, erc20, recipientAddress, amount)
+}
+
+// mint creates amount token to the recipient, the caller is checked by the invoke function
+func (cc *Controller) mint(stub fabric.Stub, erc20 *model.ERC20Metadata, recipientAddress, amount string) fabric.Response {
+	// check the token is not paused
+	if isPaused(stub) {
+		return fabric.Error("token transfers are paused")
+	}
+
+	// check amount is integer & positive
+	amountInt, err := util.ConverToPositive(amount, "mintAmount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// check the total supply does not overflow, the balances are bounded by it
+	if erc20.TotalSupply > uint64(maxAmount-amountInt) {
+		return fabric.Error("total supply overflows with the mint amount")
+	}
+
+	// record total supply before the change for the current snapshot
+	updateSupplySnapshot(stub, erc20)
+
+	// save the recipient's amount & total supply
+	recipientAmountInt, err := credit(cc.getStore(stub), recipientAddress, amountInt)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// record balance before the change for the current snapshot
+	updateBalanceSnapshot(stub, recipientAddress, recipientAmountInt)
+
+	erc20.TotalSupply += uint64(amountInt)
+	cc.putMetadata(stub, erc20)
+
+	// add voting power to the recipient's delegatee
+	moveVotingPower(stub, "", recipientAddress, amountInt)
+
+	// emit transfer event
+	transferedEvent := model.TransferedEvent{
+		Sender:          "",
+		Recipient:       recipientAddress,
+		TransferedMoney: amount}
+
+	transferedEventBytes, err := json.Marshal(transferedEvent)
+	CheckErr(err, "failed to json.Marshal(transferedEvent)")
+
+	err = stub.SetEvent("transferEvent", transferedEventBytes)
+	CheckErr(err, `failed to stub.SetEvent("transferEvent", transferedEventBytes)`)
+
+	return fabric.Success([]byte("mint func success"))
 }
 
-// Burn is ...
-func (cc *Controller) Burn(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	return shim.Success(nil)
+// Burn is invoke function that destroys amount token of the caller /
+// decreasing the total supply /
+// params - tokenName, amount of token.
+func (cc *Controller) Burn(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	tokenName, amount := params[0], params[1]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// multisig accounts cannot burn
+	if getMultisig(stub, callerAddress) != nil {
+		return fabric.Error("multisig account cannot burn")
+	}
+
+	// check the token is not paused
+	if isPaused(stub) {
+		return fabric.Error("token transfers are paused")
+	}
+
+	// check amount is integer & positive
+	amountInt, err := util.ConverToPositive(amount, "burnAmount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+
+	balances := cc.getStore(stub)
+
+	// get caller amount
+	callerAmountInt, exists, err := balances.GetBalance(callerAddress)
+	CheckErr(err, "failed to balances.GetBalance(callerAddress)")
+	if !exists {
+		return fabric.Error("caller's balance does not exist in the DB")
+	}
+
+	if callerAmountInt < amountInt {
+		return fabric.Error("caller's amount must be over the burned money")
+	}
+
+	// check & record the caller's spending limits
+	limitResponse := spendOutflow(stub, callerAddress, amountInt)
+	if limitResponse.Status >= 400 {
+		return limitResponse
+	}
+
+	// record balance & total supply before the change for the current snapshot
+	updateBalanceSnapshot(stub, callerAddress, callerAmountInt)
+	updateSupplySnapshot(stub, erc20)
+
+	// save the caller's amount & total supply
+	_, err = debit(balances, callerAddress, amountInt)
+	CheckErr(err, "failed to debit(balances, callerAddress, amountInt)")
+
+	erc20.TotalSupply -= uint64(amountInt)
+	cc.putMetadata(stub, erc20)
+
+	// remove voting power from the caller's delegatee
+	moveVotingPower(stub, callerAddress, "", amountInt)
+
+	// emit transfer event
+	transferedEvent := model.TransferedEvent{
+		Sender:          callerAddress,
+		Recipient:       "",
+		TransferedMoney: amount}
+
+	transferedEventBytes, err := json.Marshal(transferedEvent)
+	CheckErr(err, "failed to json.Marshal(transferedEvent)")
+
+	err = stub.SetEvent("transferEvent", transferedEventBytes)
+	CheckErr(err, `failed to stub.SetEvent("transferEvent", transferedEventBytes)`)
+
+	return fabric.Success([]byte("burn func success"))
 }
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/store"
+	"strconv"
+	"testing"
+)
+
+// expectAllowance checks the committed allowance of spender over the owner tokens
+func expectAllowance(l *testLedger, ownerAddress, spenderAddress, expected string) {
+	l.t.Helper()
+
+	if allowance := l.mustInvoke("", l.cc.Allowance, ownerAddress, spenderAddress); allowance != expected {
+		l.t.Fatalf("allowance of %s over %s: expected %s, got %s", spenderAddress, ownerAddress, expected, allowance)
+	}
+}
+
+func TestApproveWithExpiry(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	expiresAt := strconv.FormatInt(l.now+3600, 10)
+
+	l.mustFail("alice", "expiresAt must be a unix timestamp", l.cc.ApproveWithExpiry, "alice", "bob", "100", "tomorrow")
+	l.mustFail("alice", "expiresAt must be in the future", l.cc.ApproveWithExpiry, "alice", "bob", "100", strconv.FormatInt(l.now, 10))
+
+	l.mustInvoke("alice", l.cc.ApproveWithExpiry, "alice", "bob", "100", expiresAt)
+	approvalEvent := model.ApprovalEvent{}
+	if err := json.Unmarshal(l.event.payload, &approvalEvent); err != nil {
+		t.Fatal(err)
+	}
+	if approvalEvent.ExpiresAt != l.now+3600 {
+		t.Fatalf("expected the expiry in the approval event, got %+v", approvalEvent)
+	}
+
+	// increasing & spending the allowance keep the expiry
+	l.mustInvoke("alice", l.cc.IncreaseAllowance, "alice", "bob", "50")
+	l.mustInvoke("bob", l.cc.TransferFrom, "alice", "carol", "30")
+	expectAllowance(l, "alice", "bob", "120")
+
+	// the expired allowance is zero
+	l.now += 3600
+	expectAllowance(l, "alice", "bob", "0")
+	l.mustFail("bob", "spender's allowance must be over the transfered money", l.cc.TransferFrom, "alice", "carol", "1")
+	l.mustFail("alice", "allowance is expired, use approve or approveWithExpiry", l.cc.IncreaseAllowance, "alice", "bob", "50")
+
+	approvals := []model.ApprovalEvent{}
+	if err := json.Unmarshal([]byte(l.mustInvoke("", l.cc.ApprovalList, "alice")), &approvals); err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || approvals[0].Amount != 0 || approvals[0].ExpiresAt != l.now {
+		t.Fatalf("expected the expired approval of bob, got %+v", approvals)
+	}
+
+	// approve without the expiry resets it
+	l.mustInvoke("alice", l.cc.Approve, "alice", "bob", "10")
+	expectAllowance(l, "alice", "bob", "10")
+	l.expectBalances(map[string]int{"alice": 970, "carol": 30})
+}
+
+func TestLegacyAllowance(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+
+	// the allowances saved before the expiry are plain amounts without the expiry
+	approvalKey, _ := (&txStub{}).CreateCompositeKey("approval", []string{"alice", "bob"})
+	l.state[approvalKey] = []byte("100")
+
+	l.now += 365 * 24 * 3600
+	expectAllowance(l, "alice", "bob", "100")
+	l.mustInvoke("bob", l.cc.TransferFrom, "alice", "carol", "40")
+	expectAllowance(l, "alice", "bob", "60")
+}
+
+// newMemoryLedger deploys the token with the owner's balance on the controller keeping the token state in memory
+func newMemoryLedger(t *testing.T, owner string, supply int) (*testLedger, *store.MemoryStore) {
+	tokenStore := store.NewMemoryStore()
+	l := newTestLedger(t)
+	l.cc = NewControllerWithStore(func(fabric.Stub) store.Store { return tokenStore })
+	l.mustInvoke("", l.cc.Init, "token", "TKN", owner, strconv.Itoa(supply))
+
+	return l, tokenStore
+}
+
+// expectMemoryBalances checks the balances in the memory store
+func expectMemoryBalances(t *testing.T, tokenStore *store.MemoryStore, balances map[string]int) {
+	t.Helper()
+
+	for address, expected := range balances {
+		if balance, _, _ := tokenStore.GetBalance(address); balance != expected {
+			t.Fatalf("balance of %s: expected %d, got %d", address, expected, balance)
+		}
+	}
+}
+
+func TestMemoryStoreTransfers(t *testing.T) {
+	l, tokenStore := newMemoryLedger(t, "owner", 1000)
+
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "300")
+	l.mustFail("alice", "caller's amount must be over the transfered money", l.cc.Transfer, "alice", "bob", "301")
+	expectMemoryBalances(t, tokenStore, map[string]int{"owner": 700, "alice": 300})
+
+	l.mustInvoke("alice", l.cc.Approve, "alice", "bob", "100")
+	l.mustInvoke("bob", l.cc.TransferFrom, "alice", "carol", "60")
+	l.mustFail("bob", "spender's allowance must be over the transfered money", l.cc.TransferFrom, "alice", "carol", "41")
+	expectMemoryBalances(t, tokenStore, map[string]int{"alice": 240, "carol": 60})
+	if allowance, _ := tokenStore.GetAllowance("alice", "bob"); allowance.Amount != 40 {
+		t.Fatalf("expected the allowance 40, got %d", allowance.Amount)
+	}
+
+	// the token state is not written to the ledger
+	if _, ok := l.state["owner"]; ok {
+		t.Fatal("the balances must be kept in the memory store")
+	}
+}
+
+func TestMemoryStoreMintAndBurn(t *testing.T) {
+	l, tokenStore := newMemoryLedger(t, "owner", 1000)
+
+	l.mustFail("alice", "only the owner can mint", l.cc.Mint, "token", "alice", "500")
+	l.mustInvoke("owner", l.cc.Mint, "token", "alice", "500")
+	l.mustInvoke("alice", l.cc.Burn, "token", "200")
+	l.mustFail("alice", "caller's amount must be over the burned money", l.cc.Burn, "token", "301")
+
+	expectMemoryBalances(t, tokenStore, map[string]int{"owner": 1000, "alice": 300})
+	if erc20, _ := tokenStore.GetMetadata("token"); erc20 == nil || erc20.TotalSupply != 1300 {
+		t.Fatalf("expected the total supply 1300, got %+v", erc20)
+	}
+	if totalSupply := l.mustInvoke("", l.cc.TotalSupply, "token"); totalSupply != "1300" {
+		t.Fatalf("expected the total supply 1300, got %s", totalSupply)
+	}
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"math/big"
+	"strconv"
+)
+
+// RegisterKey is invoke function that registers the public key /
+// used to sign off-chain messages for the caller's address /
+// params - public key(PEM, ECDSA P-256 or Ed25519).
+// Returns the caller's address.
+func (cc *Controller) RegisterKey(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	pubKeyPEM := params[0]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the key format
+	_, err = parsePublicKey(pubKeyPEM)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// the registered key can only be changed with rotateKey
+	if getPublicKey(stub, callerAddress) != nil {
+		return fabric.Error("public key is already registered, use rotateKey")
+	}
+
+	putPublicKey(stub, callerAddress, pubKeyPEM)
+
+	return fabric.Success([]byte(callerAddress))
+}
+
+// RotateKey is invoke function that replaces the registered public key of the address /
+// with the signature of the old key /
+// params - address, new public key(PEM), /
+// signature of the old key over the model.KeyRotation json of this channel, chaincode and token.
+func (cc *Controller) RotateKey(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is three
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	address, newKeyPEM, signature := params[0], params[1], params[2]
+
+	// check the new key format
+	_, err := parsePublicKey(newKeyPEM)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// verify signature of the old key
+	oldKey := getPublicKey(stub, address)
+	if oldKey == nil {
+		return fabric.Error("address has no registered public key")
+	}
+
+	chaincodeName, tokenName, err := getSigningDomain(stub)
+	if err != nil {
+		return fabric.Error("failed to get the signing domain, err: " + err.Error())
+	}
+
+	nonce := strconv.Itoa(getNonce(stub, address))
+	keyRotation := model.NewKeyRotation(stub.GetChannelID(), chaincodeName, tokenName, address, newKeyPEM, nonce)
+	keyRotationBytes, err := json.Marshal(keyRotation)
+	CheckErr(err, "failed to json.Marshal(keyRotation)")
+
+	if !verifySignature(oldKey, keyRotationBytes, signature) {
+		return fabric.Error("invalid signature")
+	}
+
+	// increase nonce & save the new key
+	increaseNonce(stub, address)
+	putPublicKey(stub, address, newKeyPEM)
+
+	return fabric.Success([]byte("rotateKey func success"))
+}
+
+// KeyOf is query function
+// params - address
+// Returns the registered public key(PEM) of the address
+func (cc *Controller) KeyOf(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	publicKeyKey, err := stub.CreateCompositeKey("publicKey", []string{params[0]})
+	CheckErr(err, "failed to make a composite key for publicKey")
+
+	pubKeyPEM, err := stub.GetState(publicKeyKey)
+	CheckErr(err, "failed to stub.GetState(publicKeyKey)")
+	if pubKeyPEM == nil {
+		return fabric.Error("address has no registered public key")
+	}
+
+	return fabric.Success(pubKeyPEM)
+}
+
+// CallerAddress is query function
+// Returns the address derived from the caller's identity
+func (cc *Controller) CallerAddress(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 0 {
+		return fabric.Error("the number of params must be zero")
+	}
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	return fabric.Success([]byte(callerAddress))
+}
+
+// getPublicKey gets the registered public key of the address, nil if it does not exist
+func getPublicKey(stub fabric.Stub, address string) crypto.PublicKey {
+	publicKeyKey, err := stub.CreateCompositeKey("publicKey", []string{address})
+	CheckErr(err, "failed to make a composite key for publicKey")
+
+	pubKeyPEM, err := stub.GetState(publicKeyKey)
+	CheckErr(err, "failed to stub.GetState(publicKeyKey)")
+	if pubKeyPEM == nil {
+		return nil
+	}
+
+	publicKey, err := parsePublicKey(string(pubKeyPEM))
+	CheckErr(err, "failed to parsePublicKey(pubKeyPEM)")
+
+	return publicKey
+}
+
+// putPublicKey saves the public key of the address
+func putPublicKey(stub fabric.Stub, address, pubKeyPEM string) {
+	publicKeyKey, err := stub.CreateCompositeKey("publicKey", []string{address})
+	CheckErr(err, "failed to make a composite key for publicKey")
+
+	err = stub.PutState(publicKeyKey, []byte(pubKeyPEM))
+	CheckErr(err, "failed to stub.PutState(publicKeyKey, pubKeyPEM)")
+}
+
+// parsePublicKey parses the PEM encoded ECDSA P-256 or Ed25519 public key
+func parsePublicKey(pubKeyPEM string) (crypto.PublicKey, error) {
+	block, rest := pem.Decode([]byte(pubKeyPEM))
+	if block == nil || block.Type != "PUBLIC KEY" || len(rest) != 0 {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "publicKey",
+			Message:    "must be a single PEM encoded PUBLIC KEY block"}
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "publicKey",
+			Message:    err.Error()}
+	}
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, &model.CustomError{
+				ErrorType:  model.ConvertErrorType,
+				TargetName: "publicKey",
+				Message:    "ECDSA key must be P-256"}
+		}
+		return key, nil
+	case ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "publicKey",
+			Message:    "must be ECDSA P-256 or Ed25519"}
+	}
+}
+
+// verifySignature verifies the base64 encoded signature over the message: /
+// ASN.1 ECDSA over sha256 of the message, or Ed25519 over the message
+func verifySignature(publicKey crypto.PublicKey, message []byte, signature string) bool {
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		ecdsaSignature := struct{ R, S *big.Int }{}
+		rest, err := asn1.Unmarshal(signatureBytes, &ecdsaSignature)
+		if err != nil || len(rest) != 0 {
+			return false
+		}
+
+		hash := sha256.Sum256(message)
+
+		return ecdsa.Verify(key, hash[:], ecdsaSignature.R, ecdsaSignature.S)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signatureBytes)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"hypherledgertest2/model"
+	"testing"
+)
+
+func TestRegisterKeyAsCaller(t *testing.T) {
+	l := newTestLedger(t)
+	key := newSigningKey(t)
+
+	l.mustFail("alice", "the number of params must be one", l.cc.RegisterKey, "alice", key.publicKeyPEM())
+	l.mustFail("alice", "", l.cc.RegisterKey, "not a key")
+	if address := l.mustInvoke("alice", l.cc.RegisterKey, key.publicKeyPEM()); address != "alice" {
+		t.Fatalf("expected the address of the caller, got %s", address)
+	}
+	l.mustFail("alice", "public key is already registered, use rotateKey", l.cc.RegisterKey, newSigningKey(t).publicKeyPEM())
+
+	if registered := l.mustInvoke("", l.cc.KeyOf, "alice"); registered != key.publicKeyPEM() {
+		t.Fatalf("expected the registered key, got %s", registered)
+	}
+}
+
+func TestKeyRotationDomain(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+	oldKey, newKey := newSigningKey(t), newSigningKey(t)
+	l.mustInvoke("alice", l.cc.RegisterKey, oldKey.publicKeyPEM())
+
+	rotation := func(chaincode, token string, signer *signingKey) string {
+		return signer.sign(model.NewKeyRotation("mychannel", chaincode, token, "alice", newKey.publicKeyPEM(), "0"))
+	}
+
+	// the rotations of the other chaincodes and tokens cannot be replayed
+	for _, test := range []struct{ chaincode, token string }{
+		{"other", "token"},
+		{"erc20", "other"},
+		{"", ""},
+	} {
+		l.mustFail("", "invalid signature", l.cc.RotateKey, "alice", newKey.publicKeyPEM(), rotation(test.chaincode, test.token, oldKey))
+	}
+
+	// the rotation must be signed by the old key
+	l.mustFail("", "invalid signature", l.cc.RotateKey, "alice", newKey.publicKeyPEM(), rotation("erc20", "token", newKey))
+	l.mustInvoke("", l.cc.RotateKey, "alice", newKey.publicKeyPEM(), rotation("erc20", "token", oldKey))
+
+	if registered := l.mustInvoke("", l.cc.KeyOf, "alice"); registered != newKey.publicKeyPEM() {
+		t.Fatalf("expected the rotated key, got %s", registered)
+	}
+	if nonce := l.mustInvoke("", l.cc.Nonces, "alice"); nonce != "1" {
+		t.Fatalf("expected the nonce 1 after the rotation, got %s", nonce)
+	}
+}
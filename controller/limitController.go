@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+	"time"
+)
+
+// SpendingLimitExceededStatus is the status of the response when a spending limit would be exceeded
+const SpendingLimitExceededStatus = 429
+
+// SetComplianceOfficer is invoke function that grants or revokes the compliance role. /
+// The caller must be the owner of the token /
+// params - tokenName, officer's address, enabled(true or false).
+func (cc *Controller) SetComplianceOfficer(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is three
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	tokenName, officerAddress := params[0], params[1]
+
+	enabled, err := strconv.ParseBool(params[2])
+	if err != nil {
+		return fabric.Error("enabled must be true or false")
+	}
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the caller is the owner of the token
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+	if erc20.Owner != callerAddress {
+		return fabric.Error("only the owner can set the compliance officer")
+	}
+
+	roleKey, err := stub.CreateCompositeKey("role", []string{"compliance", officerAddress})
+	CheckErr(err, "failed to make a composite key for role")
+
+	if enabled {
+		err = stub.PutState(roleKey, []byte("true"))
+		CheckErr(err, "failed to stub.PutState(roleKey, true)")
+	} else {
+		err = stub.DelState(roleKey)
+		CheckErr(err, "failed to stub.DelState(roleKey)")
+	}
+
+	return fabric.Success([]byte("setComplianceOfficer func success"))
+}
+
+// SetSpendingLimit is invoke function that sets the daily and monthly outflow caps of the account. /
+// The caller must have the compliance role /
+// params - account's address, daily limit, monthly limit(0 means no limit).
+func (cc *Controller) SetSpendingLimit(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is three
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	accountAddress := params[0]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the caller has the compliance role
+	roleKey, err := stub.CreateCompositeKey("role", []string{"compliance", callerAddress})
+	CheckErr(err, "failed to make a composite key for role")
+
+	roleBytes, err := stub.GetState(roleKey)
+	CheckErr(err, "failed to stub.GetState(roleKey)")
+	if roleBytes == nil {
+		return fabric.Error("only the compliance officer can set the spending limit")
+	}
+
+	daily, err := strconv.Atoi(params[1])
+	if err != nil || daily < 0 {
+		return fabric.Error("daily limit must be a number and cannot be negative")
+	}
+
+	monthly, err := strconv.Atoi(params[2])
+	if err != nil || monthly < 0 {
+		return fabric.Error("monthly limit must be a number and cannot be negative")
+	}
+
+	limitKey, err := stub.CreateCompositeKey("spendingLimit", []string{accountAddress})
+	CheckErr(err, "failed to make a composite key for spendingLimit")
+
+	limitBytes, err := json.Marshal(model.SpendingLimit{Daily: daily, Monthly: monthly})
+	CheckErr(err, "failed to json.Marshal(spendingLimit)")
+
+	err = stub.PutState(limitKey, limitBytes)
+	CheckErr(err, "failed to stub.PutState(limitKey, limitBytes)")
+
+	return fabric.Success([]byte("setSpendingLimit func success"))
+}
+
+// RemainingLimit is query function
+// params - address
+// Returns the amount the address can still send in the current day and month, -1 means no limit. /
+// The remaining amount is 0 if the limit was lowered under the spent amount.
+func (cc *Controller) RemainingLimit(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	address := params[0]
+	limit := getSpendingLimit(stub, address)
+	dayBucket, monthBucket := spendingBuckets(stub)
+
+	remaining := model.RemainingLimit{Daily: -1, Monthly: -1}
+	if limit.Daily > 0 {
+		remaining.Daily = remainingAmount(limit.Daily, getSpent(stub, address, dayBucket))
+	}
+	if limit.Monthly > 0 {
+		remaining.Monthly = remainingAmount(limit.Monthly, getSpent(stub, address, monthBucket))
+	}
+
+	remainingBytes, err := json.Marshal(remaining)
+	CheckErr(err, "failed to json.Marshal(remaining)")
+
+	return fabric.Success(remainingBytes)
+}
+
+// remainingAmount gets the amount under the limit, 0 if the spent amount is over the limit
+func remainingAmount(limit, spent int) int {
+	if spent > limit {
+		return 0
+	}
+
+	return limit - spent
+}
+
+// spendOutflow checks the outflow of the account is within its limits and adds it to the spent amounts.
+// Returns the SpendingLimitExceededStatus response if a limit would be exceeded.
+func spendOutflow(stub fabric.Stub, address string, amount int) fabric.Response {
+	// the escrows of the chaincode release the tokens already limited when they were escrowed
+	if isEscrowAddress(address) {
+		return fabric.Success(nil)
+	}
+
+	limit := getSpendingLimit(stub, address)
+	if limit.Daily == 0 && limit.Monthly == 0 {
+		return fabric.Success(nil)
+	}
+
+	dayBucket, monthBucket := spendingBuckets(stub)
+	daySpent := getSpent(stub, address, dayBucket) + amount
+	monthSpent := getSpent(stub, address, monthBucket) + amount
+
+	if limit.Daily > 0 && daySpent > limit.Daily {
+		return fabric.Response{Status: SpendingLimitExceededStatus, Message: "daily spending limit exceeded"}
+	}
+	if limit.Monthly > 0 && monthSpent > limit.Monthly {
+		return fabric.Response{Status: SpendingLimitExceededStatus, Message: "monthly spending limit exceeded"}
+	}
+
+	putSpent(stub, address, dayBucket, daySpent)
+	putSpent(stub, address, monthBucket, monthSpent)
+
+	return fabric.Success(nil)
+}
+
+// spendingBuckets gets the day(2006-01-02) and month(2006-01) of the transaction timestamp in UTC
+func spendingBuckets(stub fabric.Stub) (string, string) {
+	txTime := time.Unix(getTxUnixTime(stub), 0).UTC()
+
+	return txTime.Format("2006-01-02"), txTime.Format("2006-01")
+}
+
+// getSpendingLimit gets the limits of the account, no limit if not set
+func getSpendingLimit(stub fabric.Stub, address string) *model.SpendingLimit {
+	limitKey, err := stub.CreateCompositeKey("spendingLimit", []string{address})
+	CheckErr(err, "failed to make a composite key for spendingLimit")
+
+	limitBytes, err := stub.GetState(limitKey)
+	CheckErr(err, "failed to stub.GetState(limitKey)")
+
+	limit := model.SpendingLimit{}
+	if limitBytes == nil {
+		return &limit
+	}
+
+	err = json.Unmarshal(limitBytes, &limit)
+	CheckErr(err, "failed to json.Unmarshal(limitBytes, &limit)")
+
+	return &limit
+}
+
+// getSpent gets the amount spent by the account in the bucket
+func getSpent(stub fabric.Stub, address, bucket string) int {
+	spentKey, err := stub.CreateCompositeKey("spent", []string{address, bucket})
+	CheckErr(err, "failed to make a composite key for spent")
+
+	spentBytes, err := stub.GetState(spentKey)
+	CheckErr(err, "failed to stub.GetState(spentKey)")
+	if spentBytes == nil {
+		return 0
+	}
+
+	spent, err := strconv.Atoi(string(spentBytes))
+	CheckErr(err, "failed to strconv.Atoi(string(spentBytes))")
+
+	return spent
+}
+
+// putSpent saves the amount spent by the account in the bucket
+func putSpent(stub fabric.Stub, address, bucket string, spent int) {
+	spentKey, err := stub.CreateCompositeKey("spent", []string{address, bucket})
+	CheckErr(err, "failed to make a composite key for spent")
+
+	err = stub.PutState(spentKey, []byte(strconv.Itoa(spent)))
+	CheckErr(err, "failed to stub.PutState(spentKey, spent)")
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+	"testing"
+)
+
+// expectRemainingLimit checks the committed remaining limit of the address
+func expectRemainingLimit(l *testLedger, address string, expected model.RemainingLimit) {
+	l.t.Helper()
+
+	remaining := model.RemainingLimit{}
+	if err := json.Unmarshal([]byte(l.mustInvoke("", l.cc.RemainingLimit, address)), &remaining); err != nil {
+		l.t.Fatal(err)
+	}
+	if remaining != expected {
+		l.t.Fatalf("remaining limit of %s: expected %+v, got %+v", address, expected, remaining)
+	}
+}
+
+func TestSpendingLimitRolesAsCaller(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+
+	// the owner & the officer are the caller, not a param
+	l.mustFail("alice", "the number of params must be three", l.cc.SetComplianceOfficer, "token", "owner", "alice", "true")
+	l.mustFail("alice", "only the owner can set the compliance officer", l.cc.SetComplianceOfficer, "token", "alice", "true")
+	l.mustInvoke("owner", l.cc.SetComplianceOfficer, "token", "officer", "true")
+
+	l.mustFail("alice", "the number of params must be three", l.cc.SetSpendingLimit, "officer", "owner", "100", "0")
+	l.mustFail("alice", "only the compliance officer can set the spending limit", l.cc.SetSpendingLimit, "owner", "100", "0")
+	l.mustInvoke("officer", l.cc.SetSpendingLimit, "owner", "100", "0")
+	expectRemainingLimit(l, "owner", model.RemainingLimit{Daily: 100, Monthly: -1})
+
+	l.mustInvoke("owner", l.cc.SetComplianceOfficer, "token", "officer", "false")
+	l.mustFail("officer", "only the compliance officer can set the spending limit", l.cc.SetSpendingLimit, "owner", "0", "0")
+}
+
+func TestSpendingLimitOutflows(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+	l.mustInvoke("owner", l.cc.SetComplianceOfficer, "token", "officer", "true")
+	l.mustInvoke("officer", l.cc.SetSpendingLimit, "owner", "100", "150")
+	l.mustInvoke("owner", l.cc.Approve, "owner", "spender", "1000")
+
+	// transfer, transferFrom & burn spend the same limit
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "40")
+	l.mustInvoke("spender", l.cc.TransferFrom, "owner", "alice", "30")
+	l.mustInvoke("owner", l.cc.Burn, "token", "20")
+	expectRemainingLimit(l, "owner", model.RemainingLimit{Daily: 10, Monthly: 60})
+
+	for _, res := range []struct {
+		name   string
+		status int32
+	}{
+		{"transfer", l.invoke("owner", l.cc.Transfer, "owner", "alice", "11").Status},
+		{"transferFrom", l.invoke("spender", l.cc.TransferFrom, "owner", "alice", "11").Status},
+		{"burn", l.invoke("owner", l.cc.Burn, "token", "11").Status},
+	} {
+		if res.status != SpendingLimitExceededStatus {
+			t.Errorf("%s over the daily limit: expected the status %d, got %d", res.name, SpendingLimitExceededStatus, res.status)
+		}
+	}
+	// the rejected outflows are not spent
+	expectRemainingLimit(l, "owner", model.RemainingLimit{Daily: 10, Monthly: 60})
+
+	// the remaining limit is 0 when the limit is lowered under the spent amount
+	l.mustInvoke("officer", l.cc.SetSpendingLimit, "owner", "50", "80")
+	expectRemainingLimit(l, "owner", model.RemainingLimit{Daily: 0, Monthly: 0})
+
+	l.now += 24 * 3600
+	expectRemainingLimit(l, "owner", model.RemainingLimit{Daily: 50, Monthly: 0})
+	l.expectBalances(map[string]int{"owner": 910, "alice": 70})
+}
+
+func TestSpendingLimitWrappedOutflows(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+	l.chaincodes["market"] = func(args []string) fabric.Response {
+		return fabric.Success(nil)
+	}
+	multisigAddress := l.mustInvoke("", l.cc.CreateMultisig, `["alice", "bob"]`, "2")
+	l.mustInvoke("owner", l.cc.Transfer, "owner", multisigAddress, "200")
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "100")
+	l.mustInvoke("owner", l.cc.Snapshot, "token")
+
+	l.mustInvoke("owner", l.cc.SetComplianceOfficer, "token", "officer", "true")
+	l.mustInvoke("officer", l.cc.SetSpendingLimit, "owner", "100", "0")
+	l.mustInvoke("officer", l.cc.SetSpendingLimit, multisigAddress, "100", "0")
+	transferID := l.mustInvoke("alice", l.cc.ProposeTransfer, multisigAddress, "dave", "101")
+
+	// the functions moving the tokens of the caller keep the status of the exceeded limit
+	for _, res := range []struct {
+		name   string
+		status int32
+	}{
+		{"transferAndCall", l.invoke("owner", l.cc.TransferAndCall, "owner", "market", "101", "order").Status},
+		{"createVesting", l.invoke("owner", l.cc.CreateVesting, "alice", "101", strconv.FormatInt(l.now, 10), "0", "3600", "false").Status},
+		{"createDistribution", l.invoke("owner", l.cc.CreateDistribution, "token", "1", "101", "", strconv.FormatInt(l.now+3600, 10)).Status},
+		{"privateDeposit", l.invokeTransient("owner", privateAmount("101", "salt"), l.cc.PrivateDeposit).Status},
+		{"confirm", l.invoke("bob", l.cc.Confirm, multisigAddress, transferID).Status},
+	} {
+		if res.status != SpendingLimitExceededStatus {
+			t.Errorf("%s over the daily limit: expected the status %d, got %d", res.name, SpendingLimitExceededStatus, res.status)
+		}
+	}
+
+	// the escrows release the tokens whatever limit is set on them
+	if res := l.invokeTransient("alice", privateAmount("50", "salt"), l.cc.PrivateDeposit); res.Status >= 400 {
+		t.Fatal(res.Message)
+	}
+	l.mustInvoke("officer", l.cc.SetSpendingLimit, PrivateEscrowAddress, "10", "10")
+	if res := l.invokeTransient("alice", privateAmount("50", "salt2"), l.cc.PrivateWithdraw); res.Status >= 400 {
+		t.Fatal(res.Message)
+	}
+	l.expectBalances(map[string]int{"owner": 700, "alice": 100, multisigAddress: 200, PrivateEscrowAddress: 0})
+}
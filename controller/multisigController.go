@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/util"
+	"strconv"
+)
+
+// CreateMultisig is invoke function that creates the account /
+// moved only by the confirmations of threshold signers /
+// params - signers' addresses json(["address1", "address2"]), threshold.
+// Returns the address of the multisig account.
+func (cc *Controller) CreateMultisig(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	signers := []string{}
+	err := json.Unmarshal([]byte(params[0]), &signers)
+	if err != nil || len(signers) == 0 {
+		return fabric.Error("signers must be a json array of addresses")
+	}
+
+	// check signers are unique
+	signerSet := map[string]bool{}
+	for _, signer := range signers {
+		if len(signer) == 0 || signerSet[signer] {
+			return fabric.Error("signers must be unique and cannot be empty")
+		}
+		signerSet[signer] = true
+	}
+
+	threshold, err := util.ConverToPositive(params[1], "threshold")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+	if threshold > len(signers) {
+		return fabric.Error("threshold cannot be over the number of signers")
+	}
+
+	// derive the address from the transaction
+	hash := sha256.Sum256([]byte("multisig" + stub.GetTxID()))
+	multisig := model.Multisig{Address: hex.EncodeToString(hash[:20]), Signers: signers, Threshold: threshold}
+
+	multisigKey, err := stub.CreateCompositeKey("multisig", []string{multisig.Address})
+	CheckErr(err, "failed to make a composite key for multisig")
+
+	multisigBytes, err := json.Marshal(multisig)
+	CheckErr(err, "failed to json.Marshal(multisig)")
+
+	err = stub.PutState(multisigKey, multisigBytes)
+	CheckErr(err, "failed to stub.PutState(multisigKey, multisigBytes)")
+
+	return fabric.Success([]byte(multisig.Address))
+}
+
+// ProposeTransfer is invoke function that proposes a transfer from the multisig account, /
+// confirmed by the caller who must be a signer /
+// params - multisig address, recipient's address, amount of token.
+// Returns the id of the proposed transfer.
+func (cc *Controller) ProposeTransfer(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is three
+	if len(params) != 3 {
+		return fabric.Error("the number of params must be three")
+	}
+
+	multisigAddress, recipientAddress, amount := params[0], params[1], params[2]
+
+	// check amount is integer & positive
+	amountInt, err := util.ConverToPositive(amount, "proposedAmount")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	multisig := getMultisig(stub, multisigAddress)
+	if multisig == nil {
+		return fabric.Error("multisig does not exist in the ledger")
+	}
+
+	signerAddress, err := checkMultisigSigner(stub, multisig)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// the proposer confirms the transfer
+	multisigTransfer := model.MultisigTransfer{
+		ID:            stub.GetTxID(),
+		Multisig:      multisigAddress,
+		Recipient:     recipientAddress,
+		Amount:        amountInt,
+		Confirmations: []string{signerAddress}}
+
+	response := cc.executeMultisigTransfer(stub, multisig, &multisigTransfer)
+	if response.Status >= 400 {
+		return response
+	}
+
+	return fabric.Success([]byte(multisigTransfer.ID))
+}
+
+// Confirm is invoke function that confirms the proposed transfer by the caller /
+// and executes it when the threshold is reached /
+// params - multisig address, transfer id.
+func (cc *Controller) Confirm(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	multisig, multisigTransfer, signerAddress, response := getPendingMultisigTransfer(stub, params[0], params[1])
+	if response.Status >= 400 {
+		return response
+	}
+
+	for _, confirmation := range multisigTransfer.Confirmations {
+		if confirmation == signerAddress {
+			return fabric.Error("signer already confirmed the transfer")
+		}
+	}
+	multisigTransfer.Confirmations = append(multisigTransfer.Confirmations, signerAddress)
+
+	return cc.executeMultisigTransfer(stub, multisig, multisigTransfer)
+}
+
+// RevokeConfirmation is invoke function that revokes the caller's confirmation /
+// of the pending transfer /
+// params - multisig address, transfer id.
+func (cc *Controller) RevokeConfirmation(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is two
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	_, multisigTransfer, signerAddress, response := getPendingMultisigTransfer(stub, params[0], params[1])
+	if response.Status >= 400 {
+		return response
+	}
+
+	confirmations := []string{}
+	for _, confirmation := range multisigTransfer.Confirmations {
+		if confirmation != signerAddress {
+			confirmations = append(confirmations, confirmation)
+		}
+	}
+	if len(confirmations) == len(multisigTransfer.Confirmations) {
+		return fabric.Error("signer did not confirm the transfer")
+	}
+	multisigTransfer.Confirmations = confirmations
+
+	putMultisigTransfer(stub, multisigTransfer)
+
+	return fabric.Success([]byte("revokeConfirmation func success"))
+}
+
+// GetMultisig is query function
+// params - multisig address
+// Returns the signers and the threshold of the multisig account.
+func (cc *Controller) GetMultisig(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	multisig := getMultisig(stub, params[0])
+	if multisig == nil {
+		return fabric.Error("multisig does not exist in the ledger")
+	}
+
+	multisigBytes, err := json.Marshal(multisig)
+	CheckErr(err, "failed to json.Marshal(multisig)")
+
+	return fabric.Success(multisigBytes)
+}
+
+// PendingTransfers is query function
+// params - multisig address
+// Returns the transfers of the multisig account that are not executed yet.
+func (cc *Controller) PendingTransfers(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	transferIter, err := stub.GetStateByPartialCompositeKey("multisigTransfer", []string{params[0]})
+	CheckErr(err, `failed to stub.GetStateByPartialCompositeKey("multisigTransfer", []string{multisigAddress})`)
+	defer transferIter.Close()
+
+	pendingTransfers := []model.MultisigTransfer{}
+	for transferIter.HasNext() {
+		transferKeyValue, err := transferIter.Next()
+		CheckErr(err, "failed to transferIter.Next()")
+
+		multisigTransfer := model.MultisigTransfer{}
+		err = json.Unmarshal(transferKeyValue.GetValue(), &multisigTransfer)
+		CheckErr(err, "failed to json.Unmarshal(transferBytes, &multisigTransfer)")
+
+		if !multisigTransfer.Executed {
+			pendingTransfers = append(pendingTransfers, multisigTransfer)
+		}
+	}
+
+	pendingTransfersBytes, err := json.Marshal(pendingTransfers)
+	CheckErr(err, "failed to json.Marshal(pendingTransfers)")
+
+	return fabric.Success(pendingTransfersBytes)
+}
+
+// executeMultisigTransfer transfers from the multisig account if the threshold is reached /
+// and saves the transfer
+func (cc *Controller) executeMultisigTransfer(stub fabric.Stub, multisig *model.Multisig, multisigTransfer *model.MultisigTransfer) fabric.Response {
+	if len(multisigTransfer.Confirmations) >= multisig.Threshold {
+		transferResponse := cc.transfer(stub, []string{multisig.Address, multisigTransfer.Recipient, strconv.Itoa(multisigTransfer.Amount)})
+		if transferResponse.Status == SpendingLimitExceededStatus {
+			return transferResponse
+		}
+		if transferResponse.Status >= 400 {
+			return fabric.Error(`failed to cc.transfer([]string{multisigAddress, recipientAddress, amount}), err: ` + transferResponse.GetMessage())
+		}
+		multisigTransfer.Executed = true
+	}
+
+	putMultisigTransfer(stub, multisigTransfer)
+
+	return fabric.Success([]byte(strconv.FormatBool(multisigTransfer.Executed)))
+}
+
+// getPendingMultisigTransfer gets the multisig, the pending transfer and the caller who must be a signer
+func getPendingMultisigTransfer(stub fabric.Stub, multisigAddress, transferID string) (*model.Multisig, *model.MultisigTransfer, string, fabric.Response) {
+	multisig := getMultisig(stub, multisigAddress)
+	if multisig == nil {
+		return nil, nil, "", fabric.Error("multisig does not exist in the ledger")
+	}
+
+	signerAddress, err := checkMultisigSigner(stub, multisig)
+	if err != nil {
+		return nil, nil, "", fabric.Error(err.Error())
+	}
+
+	transferKey, err := stub.CreateCompositeKey("multisigTransfer", []string{multisigAddress, transferID})
+	CheckErr(err, "failed to make a composite key for multisigTransfer")
+
+	transferBytes, err := stub.GetState(transferKey)
+	CheckErr(err, "failed to stub.GetState(transferKey)")
+	if transferBytes == nil {
+		return nil, nil, "", fabric.Error("transfer does not exist in the ledger")
+	}
+
+	multisigTransfer := model.MultisigTransfer{}
+	err = json.Unmarshal(transferBytes, &multisigTransfer)
+	CheckErr(err, "failed to json.Unmarshal(transferBytes, &multisigTransfer)")
+
+	if multisigTransfer.Executed {
+		return nil, nil, "", fabric.Error("transfer is already executed")
+	}
+
+	return multisig, &multisigTransfer, signerAddress, fabric.Success(nil)
+}
+
+// checkMultisigSigner checks the creator of the transaction is a signer of the multisig
+// Returns the signer's address.
+func checkMultisigSigner(stub fabric.Stub, multisig *model.Multisig) (string, error) {
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return "", err
+	}
+
+	for _, signer := range multisig.Signers {
+		if signer == callerAddress {
+			return callerAddress, nil
+		}
+	}
+
+	return "", &model.CustomError{
+		ErrorType:  model.VerifyErrorType,
+		TargetName: "signer",
+		Message:    "caller is not a signer of the multisig"}
+}
+
+// getMultisig gets the multisig account, nil if it does not exist
+func getMultisig(stub fabric.Stub, multisigAddress string) *model.Multisig {
+	multisigKey, err := stub.CreateCompositeKey("multisig", []string{multisigAddress})
+	CheckErr(err, "failed to make a composite key for multisig")
+
+	multisigBytes, err := stub.GetState(multisigKey)
+	CheckErr(err, "failed to stub.GetState(multisigKey)")
+	if multisigBytes == nil {
+		return nil
+	}
+
+	multisig := model.Multisig{}
+	err = json.Unmarshal(multisigBytes, &multisig)
+	CheckErr(err, "failed to json.Unmarshal(multisigBytes, &multisig)")
+
+	return &multisig
+}
+
+// putMultisigTransfer saves the transfer of the multisig account
+func putMultisigTransfer(stub fabric.Stub, multisigTransfer *model.MultisigTransfer) {
+	transferKey, err := stub.CreateCompositeKey("multisigTransfer", []string{multisigTransfer.Multisig, multisigTransfer.ID})
+	CheckErr(err, "failed to make a composite key for multisigTransfer")
+
+	transferBytes, err := json.Marshal(multisigTransfer)
+	CheckErr(err, "failed to json.Marshal(multisigTransfer)")
+
+	err = stub.PutState(transferKey, transferBytes)
+	CheckErr(err, "failed to stub.PutState(transferKey, transferBytes)")
+}
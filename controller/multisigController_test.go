@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/model"
+	"testing"
+)
+
+// newMultisigLedger creates the 2 of 3 multisig account holding 1000 token
+func newMultisigLedger(t *testing.T) (*testLedger, string) {
+	l := newTokenLedger(t, "owner", 1000)
+	multisigAddress := l.mustInvoke("", l.cc.CreateMultisig, `["alice", "bob", "carol"]`, "2")
+	l.mustInvoke("owner", l.cc.Transfer, "owner", multisigAddress, "1000")
+
+	return l, multisigAddress
+}
+
+// pendingTransfers gets the committed pending transfers of the multisig account
+func pendingTransfers(l *testLedger, multisigAddress string) []model.MultisigTransfer {
+	l.t.Helper()
+
+	transfers := []model.MultisigTransfer{}
+	if err := json.Unmarshal([]byte(l.mustInvoke("", l.cc.PendingTransfers, multisigAddress)), &transfers); err != nil {
+		l.t.Fatal(err)
+	}
+
+	return transfers
+}
+
+func TestCreateMultisig(t *testing.T) {
+	l := newTestLedger(t)
+
+	l.mustFail("", "signers must be a json array of addresses", l.cc.CreateMultisig, `[]`, "1")
+	l.mustFail("", "signers must be unique and cannot be empty", l.cc.CreateMultisig, `["alice", "alice"]`, "1")
+	l.mustFail("", "threshold cannot be over the number of signers", l.cc.CreateMultisig, `["alice", "bob"]`, "3")
+
+	// the address is derived from the transaction
+	first := l.mustInvoke("", l.cc.CreateMultisig, `["alice", "bob"]`, "2")
+	second := l.mustInvoke("", l.cc.CreateMultisig, `["alice", "bob"]`, "2")
+	if len(first) != 40 || first == second {
+		t.Fatalf("expected the distinct addresses of the transactions, got %s and %s", first, second)
+	}
+}
+
+func TestMultisigTransferThreshold(t *testing.T) {
+	l, multisigAddress := newMultisigLedger(t)
+
+	// the multisig account is moved only by the confirmations
+	l.mustFail("alice", "multisig account can only transfer with the confirmations of the signers", l.cc.Transfer, multisigAddress, "alice", "100")
+	l.mustFail("mallory", "caller is not a signer of the multisig", l.cc.ProposeTransfer, multisigAddress, "mallory", "100")
+
+	if transferID := l.mustInvoke("alice", l.cc.ProposeTransfer, multisigAddress, "dave", "300"); transferID == "" {
+		t.Fatal("expected the id of the proposed transfer")
+	}
+	transfers := pendingTransfers(l, multisigAddress)
+	if len(transfers) != 1 {
+		t.Fatalf("expected the pending transfer, got %+v", transfers)
+	}
+	transferID := transfers[0].ID
+
+	l.mustFail("alice", "signer already confirmed the transfer", l.cc.Confirm, multisigAddress, transferID)
+	l.mustFail("mallory", "caller is not a signer of the multisig", l.cc.Confirm, multisigAddress, transferID)
+	l.expectBalances(map[string]int{multisigAddress: 1000, "dave": 0})
+
+	// the second confirmation reaches the threshold and executes the transfer
+	if executed := l.mustInvoke("bob", l.cc.Confirm, multisigAddress, transferID); executed != "true" {
+		t.Fatalf("expected the executed transfer, got %s", executed)
+	}
+	l.expectBalances(map[string]int{multisigAddress: 700, "dave": 300})
+	if transfers := pendingTransfers(l, multisigAddress); len(transfers) != 0 {
+		t.Fatalf("expected no pending transfer, got %+v", transfers)
+	}
+	l.mustFail("carol", "transfer is already executed", l.cc.Confirm, multisigAddress, transferID)
+}
+
+func TestMultisigRevokeConfirmation(t *testing.T) {
+	l, multisigAddress := newMultisigLedger(t)
+
+	l.mustInvoke("alice", l.cc.ProposeTransfer, multisigAddress, "dave", "300")
+	transferID := pendingTransfers(l, multisigAddress)[0].ID
+
+	l.mustFail("bob", "signer did not confirm the transfer", l.cc.RevokeConfirmation, multisigAddress, transferID)
+	l.mustInvoke("alice", l.cc.RevokeConfirmation, multisigAddress, transferID)
+
+	// the revoked confirmation does not count to the threshold
+	if executed := l.mustInvoke("bob", l.cc.Confirm, multisigAddress, transferID); executed != "false" {
+		t.Fatalf("expected the pending transfer, got %s", executed)
+	}
+	l.expectBalances(map[string]int{multisigAddress: 1000, "dave": 0})
+
+	l.mustInvoke("carol", l.cc.Confirm, multisigAddress, transferID)
+	l.expectBalances(map[string]int{multisigAddress: 700, "dave": 300})
+}
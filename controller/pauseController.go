@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"hypherledgertest2/fabric"
+	"strconv"
+)
+
+// Paused is query function
+// Returns true if the token transfers are paused
+func (cc *Controller) Paused(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 0 {
+		return fabric.Error("the number of params must be zero")
+	}
+
+	return fabric.Success([]byte(strconv.FormatBool(isPaused(stub))))
+}
+
+// isPaused checks the token transfers are paused
+func isPaused(stub fabric.Stub) bool {
+	pausedKey, err := stub.CreateCompositeKey("paused", []string{})
+	CheckErr(err, "failed to make a composite key for paused")
+
+	pausedBytes, err := stub.GetState(pausedKey)
+	CheckErr(err, "failed to stub.GetState(pausedKey)")
+
+	return string(pausedBytes) == "true"
+}
+
+// setPaused pauses or unpauses the token transfers
+func setPaused(stub fabric.Stub, paused bool) {
+	pausedKey, err := stub.CreateCompositeKey("paused", []string{})
+	CheckErr(err, "failed to make a composite key for paused")
+
+	err = stub.PutState(pausedKey, []byte(strconv.FormatBool(paused)))
+	CheckErr(err, "failed to stub.PutState(pausedKey, paused)")
+}
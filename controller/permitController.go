@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+)
+
+// Permit is invoke function that sets amount as the allowance of spender over the owner tokens /
+// with the owner's signature, so that anyone can submit the approval /
+// params - owner's address, spender's address, amount of token, nonce, deadline(unix seconds), /
+// signature(base64 signature of the registered key over the model.Permit json /
+// of this channel, chaincode and token).
+func (cc *Controller) Permit(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is six
+	if len(params) != 6 {
+		return fabric.Error("the number of params must be six")
+	}
+
+	ownerAddress, spenderAddress, amount, nonce, deadline, signature := params[0], params[1], params[2], params[3], params[4], params[5]
+
+	// check deadline
+	deadlineInt, err := strconv.ParseInt(deadline, 10, 64)
+	if err != nil {
+		return fabric.Error("deadline must be a unix timestamp")
+	}
+	if getTxUnixTime(stub) > deadlineInt {
+		return fabric.Error("permit is expired")
+	}
+
+	// check nonce
+	if nonce != strconv.Itoa(getNonce(stub, ownerAddress)) {
+		return fabric.Error("invalid nonce")
+	}
+
+	// verify signature
+	publicKey := getPublicKey(stub, ownerAddress)
+	if publicKey == nil {
+		return fabric.Error("owner has no registered public key")
+	}
+
+	chaincodeName, tokenName, err := getSigningDomain(stub)
+	if err != nil {
+		return fabric.Error("failed to get the signing domain, err: " + err.Error())
+	}
+
+	permit := model.NewPermit(stub.GetChannelID(), chaincodeName, tokenName, ownerAddress, spenderAddress, amount, nonce, deadline)
+	permitBytes, err := json.Marshal(permit)
+	CheckErr(err, "failed to json.Marshal(permit)")
+
+	if !verifySignature(publicKey, permitBytes, signature) {
+		return fabric.Error("invalid signature")
+	}
+
+	// increase nonce
+	increaseNonce(stub, ownerAddress)
+
+	// approve
+	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, amount})
+	if approveResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.Approve(ownerAddress, spenderAddress, amount), err: ` + approveResponse.GetMessage())
+	}
+
+	return fabric.Success([]byte("permit func success"))
+}
+
+// Nonces is query function
+// params - owner's address
+// Returns the nonce of the next signed message of the owner
+func (cc *Controller) Nonces(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	return fabric.Success([]byte(strconv.Itoa(getNonce(stub, params[0]))))
+}
+
+// getNonce gets the nonce of the owner, 0 if it does not exist
+func getNonce(stub fabric.Stub, ownerAddress string) int {
+	nonceKey, err := stub.CreateCompositeKey("nonce", []string{ownerAddress})
+	CheckErr(err, "failed to make a composite key for nonce")
+
+	nonceBytes, err := stub.GetState(nonceKey)
+	CheckErr(err, "failed to stub.GetState(nonceKey)")
+	if nonceBytes == nil {
+		return 0
+	}
+
+	nonce, err := strconv.Atoi(string(nonceBytes))
+	CheckErr(err, "failed to strconv.Atoi(string(nonceBytes))")
+
+	return nonce
+}
+
+// increaseNonce increases the nonce of the owner, so that a signed message cannot be replayed
+func increaseNonce(stub fabric.Stub, ownerAddress string) {
+	nonceKey, err := stub.CreateCompositeKey("nonce", []string{ownerAddress})
+	CheckErr(err, "failed to make a composite key for nonce")
+
+	err = stub.PutState(nonceKey, []byte(strconv.Itoa(getNonce(stub, ownerAddress)+1)))
+	CheckErr(err, "failed to stub.PutState(nonceKey, nonce)")
+}
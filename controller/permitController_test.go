@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"hypherledgertest2/model"
+	"strconv"
+	"testing"
+)
+
+// signingKey is the Ed25519 key of the signed messages of the tests
+type signingKey struct {
+	t          *testing.T
+	privateKey ed25519.PrivateKey
+}
+
+func newSigningKey(t *testing.T) *signingKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &signingKey{t, privateKey}
+}
+
+// publicKeyPEM gets the PEM of the public key to register
+func (k *signingKey) publicKeyPEM() string {
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(k.privateKey.Public())
+	if err != nil {
+		k.t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+}
+
+// sign gets the base64 signature over the json of the message
+func (k *signingKey) sign(message interface{}) string {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		k.t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(k.privateKey, messageBytes))
+}
+
+func TestPermitDomain(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	key := newSigningKey(t)
+	l.mustInvoke("alice", l.cc.RegisterKey, key.publicKeyPEM())
+
+	deadline := strconv.FormatInt(l.now+3600, 10)
+	permit := func(chaincode, token string) string {
+		return key.sign(model.NewPermit("mychannel", chaincode, token, "alice", "bob", "50", "0", deadline))
+	}
+
+	// the permits of the other chaincodes and tokens cannot be replayed
+	for _, test := range []struct{ chaincode, token string }{
+		{"other", "token"},
+		{"erc20", "other"},
+		{"", ""},
+	} {
+		l.mustFail("bob", "invalid signature", l.cc.Permit, "alice", "bob", "50", "0", deadline, permit(test.chaincode, test.token))
+	}
+
+	l.mustInvoke("bob", l.cc.Permit, "alice", "bob", "50", "0", deadline, permit("erc20", "token"))
+	l.mustFail("bob", "invalid nonce", l.cc.Permit, "alice", "bob", "50", "0", deadline, permit("erc20", "token"))
+	if allowance := l.mustInvoke("", l.cc.Allowance, "alice", "bob"); allowance != "50" {
+		t.Fatalf("expected the allowance 50, got %s", allowance)
+	}
+
+	l.now += 3601
+	l.mustFail("bob", "permit is expired", l.cc.Permit, "alice", "bob", "50", "1", deadline, permit("erc20", "token"))
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/util"
+	"strconv"
+)
+
+// PrivateCollection is the private data collection of the private balances, /
+// collections_config.json is the sample for the organizations Org1MSP and Org2MSP of the test network
+const PrivateCollection = "privateBalances"
+
+// PrivateEscrowAddress is the public address holding the tokens deposited to the private balances
+const PrivateEscrowAddress = "privateEscrow"
+
+// PrivateTransfer is invoke function that moves amount token /
+// from the caller's private balance to the recipient's private balance /
+// params - recipient's address /
+// transient - amount, salt.
+// Returns the salted hash recorded on the public ledger.
+func (cc *Controller) PrivateTransfer(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	recipientAddress := params[0]
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// the self-transfer would credit the amount, see applyBalanceChanges
+	if recipientAddress == callerAddress {
+		return fabric.Error("caller cannot transfer to oneself")
+	}
+
+	amount, salt, err := getPrivateAmount(stub)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// check the token is not paused
+	if isPaused(stub) {
+		return fabric.Error("token transfers are paused")
+	}
+
+	// check caller's private balance
+	callerAmount := getPrivateBalance(stub, callerAddress)
+	if callerAmount < amount {
+		return fabric.Error("caller's private amount must be over the transfered money")
+	}
+
+	// save the caller's & recipient's private amount
+	putPrivateBalance(stub, callerAddress, callerAmount-amount, salt)
+	putPrivateBalance(stub, recipientAddress, getPrivateBalance(stub, recipientAddress)+amount, salt)
+
+	// record the salted hash on the public ledger & emit event
+	hash := recordPrivateTransfer(stub, callerAddress, recipientAddress, amount, salt)
+
+	return fabric.Success([]byte(hash))
+}
+
+// PrivateDeposit is invoke function that moves amount token /
+// from the caller's public balance to the caller's private balance /
+// transient - amount, salt.
+func (cc *Controller) PrivateDeposit(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is zero
+	if len(params) != 0 {
+		return fabric.Error("the number of params must be zero")
+	}
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	amount, salt, err := getPrivateAmount(stub)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// lock the public tokens in the escrow without the transfer event of the amount
+	_, transferResponse := cc.moveTokens(stub, callerAddress, PrivateEscrowAddress, strconv.Itoa(amount))
+	if transferResponse.Status == SpendingLimitExceededStatus {
+		return transferResponse
+	}
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.moveTokens(callerAddress, PrivateEscrowAddress, amount), err: ` + transferResponse.GetMessage())
+	}
+
+	putPrivateBalance(stub, callerAddress, getPrivateBalance(stub, callerAddress)+amount, salt)
+
+	hash := recordPrivateTransfer(stub, callerAddress, callerAddress, amount, salt)
+
+	return fabric.Success([]byte(hash))
+}
+
+// PrivateWithdraw is invoke function that moves amount token /
+// from the caller's private balance to the caller's public balance /
+// transient - amount, salt.
+func (cc *Controller) PrivateWithdraw(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is zero
+	if len(params) != 0 {
+		return fabric.Error("the number of params must be zero")
+	}
+
+	callerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	amount, salt, err := getPrivateAmount(stub)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	callerAmount := getPrivateBalance(stub, callerAddress)
+	if callerAmount < amount {
+		return fabric.Error("caller's private amount must be over the withdrawn money")
+	}
+
+	putPrivateBalance(stub, callerAddress, callerAmount-amount, salt)
+
+	// release the public tokens from the escrow without the transfer event of the amount
+	_, transferResponse := cc.moveTokens(stub, PrivateEscrowAddress, callerAddress, strconv.Itoa(amount))
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.moveTokens(PrivateEscrowAddress, callerAddress, amount), err: ` + transferResponse.GetMessage())
+	}
+
+	hash := recordPrivateTransfer(stub, callerAddress, callerAddress, amount, salt)
+
+	return fabric.Success([]byte(hash))
+}
+
+// PrivateBalanceOf is query function
+// params - address
+// Returns the amount of tokens owned by the address in the private collection
+func (cc *Controller) PrivateBalanceOf(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	return fabric.Success([]byte(strconv.Itoa(getPrivateBalance(stub, params[0]))))
+}
+
+// getPrivateAmount gets the amount and the salt from the transient map
+func getPrivateAmount(stub fabric.Stub) (int, string, error) {
+	transient, err := stub.GetTransient()
+	CheckErr(err, "failed to stub.GetTransient()")
+
+	amount, err := util.ConverToPositive(string(transient["amount"]), "transientAmount")
+	if err != nil {
+		return 0, "", err
+	}
+
+	salt := string(transient["salt"])
+	if len(salt) == 0 {
+		return 0, "", &model.CustomError{
+			ErrorType:  model.GetErrorType,
+			TargetName: "transientSalt",
+			Message:    "salt cannot be empty"}
+	}
+
+	return amount, salt, nil
+}
+
+// getPrivateBalance gets the private balance of the address, 0 if it does not exist
+func getPrivateBalance(stub fabric.Stub, address string) int {
+	balanceBytes, err := stub.GetPrivateData(PrivateCollection, address)
+	CheckErr(err, "failed to stub.GetPrivateData(PrivateCollection, address)")
+	if balanceBytes == nil {
+		return 0
+	}
+
+	// the plain balance saved before the records were salted
+	balance, err := strconv.Atoi(string(balanceBytes))
+	if err == nil {
+		return balance
+	}
+
+	privateBalance := model.PrivateBalance{}
+	err = json.Unmarshal(balanceBytes, &privateBalance)
+	CheckErr(err, "failed to json.Unmarshal(balanceBytes, &privateBalance)")
+
+	return privateBalance.Balance
+}
+
+// putPrivateBalance saves the private balance of the address with the nonce of sha256(salt, txID, address), /
+// the same on every endorsing peer but unknown to the readers of the public hash of the record
+func putPrivateBalance(stub fabric.Stub, address string, balance int, salt string) {
+	nonceBytes := sha256.Sum256([]byte(salt + "\x00" + stub.GetTxID() + "\x00" + address))
+	privateBalance := model.PrivateBalance{Balance: balance, Nonce: hex.EncodeToString(nonceBytes[:])}
+
+	privateBalanceBytes, err := json.Marshal(privateBalance)
+	CheckErr(err, "failed to json.Marshal(privateBalance)")
+
+	err = stub.PutPrivateData(PrivateCollection, address, privateBalanceBytes)
+	CheckErr(err, "failed to stub.PutPrivateData(PrivateCollection, address, privateBalanceBytes)")
+}
+
+// recordPrivateTransfer saves sha256(sender, recipient, amount, salt) on the public ledger /
+// and emits the private transfer event.
+// Returns the hash.
+func recordPrivateTransfer(stub fabric.Stub, senderAddress, recipientAddress string, amount int, salt string) string {
+	hashBytes := sha256.Sum256([]byte(senderAddress + "\x00" + recipientAddress + "\x00" + strconv.Itoa(amount) + "\x00" + salt))
+	hash := hex.EncodeToString(hashBytes[:])
+
+	privateTransferKey, err := stub.CreateCompositeKey("privateTransfer", []string{stub.GetTxID()})
+	CheckErr(err, "failed to make a composite key for privateTransfer")
+
+	err = stub.PutState(privateTransferKey, []byte(hash))
+	CheckErr(err, "failed to stub.PutState(privateTransferKey, hash)")
+
+	privateTransferedEvent := model.PrivateTransferedEvent{Sender: senderAddress, Recipient: recipientAddress, Hash: hash}
+	privateTransferedEventBytes, err := json.Marshal(privateTransferedEvent)
+	CheckErr(err, "failed to json.Marshal(privateTransferedEvent)")
+
+	err = stub.SetEvent("privateTransferEvent", privateTransferedEventBytes)
+	CheckErr(err, `failed to stub.SetEvent("privateTransferEvent", privateTransferedEventBytes)`)
+
+	return hash
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/model"
+	"testing"
+)
+
+// privateAmount is the transient data of the private functions
+func privateAmount(amount, salt string) map[string][]byte {
+	return map[string][]byte{"amount": []byte(amount), "salt": []byte(salt)}
+}
+
+// expectPrivateBalances checks the committed private balances
+func expectPrivateBalances(l *testLedger, balances map[string]string) {
+	l.t.Helper()
+
+	for address, expected := range balances {
+		if balance := l.mustInvoke("", l.cc.PrivateBalanceOf, address); balance != expected {
+			l.t.Fatalf("private balance of %s: expected %s, got %s", address, expected, balance)
+		}
+	}
+}
+
+func TestPrivateDepositAndWithdraw(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+
+	// the depositor is the caller, not a param
+	if res := l.invokeTransient("alice", privateAmount("300", "salt"), l.cc.PrivateDeposit, "alice"); res.Status < 400 {
+		t.Fatal("the deposit with the params must fail")
+	}
+	if res := l.invokeTransient("bob", privateAmount("300", "salt"), l.cc.PrivateDeposit); res.Status < 400 {
+		t.Fatal("the deposit over the public balance must fail")
+	}
+
+	if res := l.invokeTransient("alice", privateAmount("300", "salt"), l.cc.PrivateDeposit); res.Status >= 400 {
+		t.Fatal(res.Message)
+	}
+	// the public transfer event would publish the private amount
+	if l.event == nil || l.event.name != "privateTransferEvent" {
+		t.Fatalf("expected only the private transfer event, got %+v", l.event)
+	}
+
+	if res := l.invokeTransient("alice", privateAmount("100", "salt2"), l.cc.PrivateWithdraw); res.Status >= 400 {
+		t.Fatal(res.Message)
+	}
+	if l.event == nil || l.event.name != "privateTransferEvent" {
+		t.Fatalf("expected only the private transfer event, got %+v", l.event)
+	}
+
+	l.expectBalances(map[string]int{"alice": 800, PrivateEscrowAddress: 200})
+	expectPrivateBalances(l, map[string]string{"alice": "200"})
+}
+
+func TestPrivateTransfer(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	l.invokeTransient("alice", privateAmount("300", "salt"), l.cc.PrivateDeposit)
+
+	// the sender is the caller, not a param
+	if res := l.invokeTransient("bob", privateAmount("100", "salt"), l.cc.PrivateTransfer, "bob"); res.Status < 400 {
+		t.Fatal("the private transfer over the private balance must fail")
+	}
+	if res := l.invokeTransient("bob", privateAmount("100", "salt"), l.cc.PrivateTransfer, "alice", "bob"); res.Status < 400 {
+		t.Fatal("the private transfer with the sender param must fail")
+	}
+
+	// the self-transfer would credit the amount on a peer
+	if res := l.invokeTransient("alice", privateAmount("100", "salt"), l.cc.PrivateTransfer, "alice"); res.Message != "caller cannot transfer to oneself" {
+		t.Fatalf("expected the self-transfer to fail, got %d %s", res.Status, res.Message)
+	}
+
+	if res := l.invokeTransient("alice", privateAmount("100", "salt"), l.cc.PrivateTransfer, "bob"); res.Status >= 400 {
+		t.Fatal(res.Message)
+	}
+	expectPrivateBalances(l, map[string]string{"alice": "200", "bob": "100"})
+	l.expectBalances(map[string]int{"alice": 700, "bob": 0, PrivateEscrowAddress: 300})
+}
+
+func TestPrivateBalanceRecordIsSalted(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	recordKey := PrivateCollection + "/alice"
+
+	// the plain balance saved before the records were salted
+	l.private[recordKey] = []byte("50")
+	expectPrivateBalances(l, map[string]string{"alice": "50"})
+
+	records := map[string]bool{}
+	for _, salt := range []string{"salt", "salt2"} {
+		if res := l.invokeTransient("alice", privateAmount("100", salt), l.cc.PrivateDeposit); res.Status >= 400 {
+			t.Fatal(res.Message)
+		}
+		if res := l.invokeTransient("alice", privateAmount("100", salt+"-withdraw"), l.cc.PrivateWithdraw); res.Status >= 400 {
+			t.Fatal(res.Message)
+		}
+
+		// the hash of the plain balance could be matched by guessing the balance
+		privateBalance := model.PrivateBalance{}
+		if err := json.Unmarshal(l.private[recordKey], &privateBalance); err != nil {
+			t.Fatalf("expected the record of the balance, got %s", l.private[recordKey])
+		}
+		if privateBalance.Balance != 50 || len(privateBalance.Nonce) == 0 {
+			t.Fatalf("expected the balance 50 with the nonce, got %+v", privateBalance)
+		}
+		records[string(l.private[recordKey])] = true
+	}
+
+	// the same balance is saved with another nonce in another transaction
+	if len(records) != 2 {
+		t.Fatalf("expected the records of the same balance to differ, got %v", records)
+	}
+	expectPrivateBalances(l, map[string]string{"alice": "50"})
+}
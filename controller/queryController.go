@@ -3,32 +3,25 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"hypherledgertest2/fabric"
 	"hypherledgertest2/model"
 	"strconv"
-
-	"github.com/hyperledger/fabric/core/chaincode/shim"
-	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
 // TotalSupply is query function
 // params - tokenName
 // Returns the amount of token in the ledge
-func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) TotalSupply(stub fabric.Stub, params []string) fabric.Response {
 	if len(params) != 1 {
-		return shim.Error("the number of params must be one")
+		return fabric.Error("the number of params must be one")
 	}
 
 	tokenName := params[0]
 
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, err := stub.GetState(tokenName) // tokenName아 없으면 erc20Bytes, err에 nil이 들어감
-	CheckErr(err, `failed to  stub.GetState("tokenName")`)
-	if erc20Bytes == nil {
-		return shim.Error("erc20Bytes is nil")
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
 	}
-
-	err = json.Unmarshal(erc20Bytes, &erc20)
-	CheckErr(err, `failed to json.Unmarshal(erc20Bytes, &erc20)`)
 	totalBalance := erc20.TotalSupply
 
 	totalBalanceBytes, err := json.Marshal(totalBalance)
@@ -36,94 +29,70 @@ func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []str
 
 	fmt.Println(tokenName + "', total supply is" + string(totalBalanceBytes))
 
-	return shim.Success(totalBalanceBytes)
+	return fabric.Success(totalBalanceBytes)
 }
 
 // BalanceOf is query function
 // params - address
 // Returns the amount of tokens owned by the addresss
-func (cc *Controller) BalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) BalanceOf(stub fabric.Stub, params []string) fabric.Response {
 	if len(params) != 1 {
-		return shim.Error("the number of params must be one")
+		return fabric.Error("the number of params must be one")
 	}
 
 	address := params[0]
 
-	balanceByte, err := stub.GetState(address)
-	CheckErr(err, `stub.GetState("owner")`)
-	if balanceByte == nil {
-		return shim.Error("balanceByte is nil")
+	balance, exists, err := cc.getStore(stub).GetBalance(address)
+	CheckErr(err, `failed to balances.GetBalance(address)`)
+	if !exists {
+		return fabric.Error("balance does not exist in the ledger")
 	}
 
-	fmt.Println(address + "'s, balance is " + string(balanceByte))
-	return shim.Success(balanceByte)
+	balanceStr := strconv.Itoa(balance)
+	fmt.Println(address + "'s, balance is " + balanceStr)
+	return fabric.Success([]byte(balanceStr))
 }
 
 // Allowance is a query function /
 // params - owner's address, spender's address /
 // Returns the remaining amount of token to invoke {transferFrom}.
-func (cc *Controller) Allowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) Allowance(stub fabric.Stub, params []string) fabric.Response {
 	// check the number of the params is 2
 	if len(params) != 2 {
-		return shim.Error("the number of params must be two")
+		return fabric.Error("the number of params must be two")
 	}
 
 	ownerAddress, spenderAddress := params[0], params[1]
 
-	// create composite key
-	approvalKey, err := stub.CreateCompositeKey("approval", []string{ownerAddress, spenderAddress})
-	CheckErr(err, "failed to make a composite key for allowance")
-
-	// get amount
-	allowanceAmount, err := stub.GetState(approvalKey)
-	CheckErr(err, "failed to get allowance amount from the ledger")
-	if allowanceAmount == nil {
-		allowanceAmount = []byte("0")
-	}
+	// get amount, zero if expired
+	allowance := cc.getAllowance(stub, ownerAddress, spenderAddress)
 
-	return shim.Success(allowanceAmount)
+	return fabric.Success([]byte(strconv.Itoa(allowanceAmount(stub, allowance))))
 }
 
 // ApprovalList is a query function.
 // params - owner's address.
 // Returns the approval list approved by owner.
-func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+func (cc *Controller) ApprovalList(stub fabric.Stub, params []string) fabric.Response {
 	// check the number of the parameters is one
 	if len(params) != 1 {
-		return shim.Error("the number of params must be one")
+		return fabric.Error("the number of params must be one")
 	}
 
 	ownerAddress := params[0]
 
-	// get all approval list (format is iterator)
-	approvalIter, err := stub.GetStateByPartialCompositeKey("approval", []string{ownerAddress})
-	CheckErr(err, `failed to stub.GetStateByPartialCompositeKey("approval", []string{ownerAddress})`)
+	// get all approval list
+	allowances, err := cc.getStore(stub).ListAllowances(ownerAddress)
+	CheckErr(err, `failed to allowances.ListAllowances(ownerAddress)`)
 
-	// make slice for return value
+	// make slice for return value, zero if expired
 	approvalSlice := []model.ApprovalEvent{}
-
-	// iterator
-	for approvalIter.HasNext() {
-		approvalKeyValue, err := approvalIter.Next()
-		CheckErr(err, `failed to approvalIter.Next()`)
-
-		_, addresses, err := stub.SplitCompositeKey(approvalKeyValue.GetKey())
-		CheckErr(err, `failed to stub.SplitCompositeKey(approvalKeyValue.GetKey())`)
-
-		// - get spender address
-		spenderAddress := addresses[1]
-
-		// - get amount
-		amount := approvalKeyValue.GetValue()
-		if amount == nil {
-			return shim.Error("amount does not exist in the ledger")
-		}
-
-		// - add approval result
-		amountInt, err := strconv.Atoi(string(amount))
-		CheckErr(err, `failed to strconv.Atoi(string(amount))`)
-
-		approval := model.ApprovalEvent{Owner: ownerAddress, Spender: spenderAddress, Amount: amountInt}
+	for _, entry := range allowances {
+		approval := model.ApprovalEvent{
+			Owner:     ownerAddress,
+			Spender:   entry.SpenderAddress,
+			Amount:    allowanceAmount(stub, entry.Allowance),
+			ExpiresAt: entry.Allowance.ExpiresAt}
 		approvalSlice = append(approvalSlice, approval)
 	}
 
@@ -131,5 +100,29 @@ func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []st
 	approvalSliceByte, err := json.Marshal(approvalSlice)
 	CheckErr(err, `failed to json.Marshal(approvalSlice)`)
 
-	return shim.Success(approvalSliceByte)
+	return fabric.Success(approvalSliceByte)
+}
+
+// getAllowance gets the allowance record of spender over the owner tokens, zero if it does not exist
+func (cc *Controller) getAllowance(stub fabric.Stub, ownerAddress, spenderAddress string) *model.Allowance {
+	allowance, err := cc.getStore(stub).GetAllowance(ownerAddress, spenderAddress)
+	CheckErr(err, "failed to get allowance amount from the ledger")
+
+	return allowance
+}
+
+// putAllowance saves the allowance record of spender over the owner tokens
+func (cc *Controller) putAllowance(stub fabric.Stub, ownerAddress, spenderAddress string, allowance *model.Allowance) {
+	err := cc.getStore(stub).PutAllowance(ownerAddress, spenderAddress, allowance)
+	CheckErr(err, "failed to allowances.PutAllowance(ownerAddress, spenderAddress, allowance)")
+}
+
+// isAllowanceExpired checks the expiry of the allowance against the transaction timestamp
+func isAllowanceExpired(stub fabric.Stub, allowance *model.Allowance) bool {
+	return isAllowanceExpiredAt(allowance, getTxUnixTime(stub))
+}
+
+// allowanceAmount gets the amount of the allowance, zero if expired
+func allowanceAmount(stub fabric.Stub, allowance *model.Allowance) int {
+	return allowanceAmountAt(allowance, getTxUnixTime(stub))
 }
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+)
+
+// signedFunctions is the whitelist of the functions that can be invoked with a signed payload /
+// and the index of the acting account in their params, -1 if the acting account is the caller
+var signedFunctions = map[string]int{
+	"transfer":          0,  // caller's address, recipient's address, amount
+	"approve":           0,  // owner's address, spender's address, amount
+	"approveWithExpiry": 0,  // owner's address, spender's address, amount, expiresAt
+	"increaseAllowance": 0,  // owner's address, spender's address, amount
+	"decreaseAllowance": 0,  // owner's address, spender's address, amount
+	"transferAndCall":   0,  // caller's address, recipient chaincode name, amount, data
+	"approveAndCall":    0,  // owner's address, spender chaincode name, amount, data
+	"transferFrom":      -1, // owner's address, recipient's address, amount
+	"burn":              -1, // tokenName, amount
+	"delegate":          -1, // delegatee's address
+	"castVote":          -1, // proposal id, support
+}
+
+// ParseSignedPayload verifies the payload signed by the signer's registered key /
+// and consumes its nonce /
+// params - payload json(model.SignedPayload), signature(base64 signature of the registered key over the payload).
+// Returns the function, the params and the stub with the signer as the acting account.
+func (cc *Controller) ParseSignedPayload(stub fabric.Stub, params []string) (string, []string, fabric.Stub, error) {
+	// check the number of params is two
+	if len(params) != 2 {
+		return "", nil, nil, signedPayloadError("the number of params must be two")
+	}
+
+	payloadJSON, signature := params[0], params[1]
+
+	payload := model.SignedPayload{}
+	err := json.Unmarshal([]byte(payloadJSON), &payload)
+	if err != nil {
+		return "", nil, nil, signedPayloadError("payload must be a json of model.SignedPayload")
+	}
+
+	// check the function is whitelisted
+	actingIndex, ok := signedFunctions[payload.Function]
+	if !ok {
+		return "", nil, nil, signedPayloadError("function cannot be invoked with a signed payload: " + payload.Function)
+	}
+	if actingIndex > len(payload.Args) {
+		return "", nil, nil, signedPayloadError("incorrect number of the args")
+	}
+
+	// check the payload is for this channel, chaincode and token, and not expired
+	if payload.Channel != stub.GetChannelID() {
+		return "", nil, nil, signedPayloadError("payload is signed for another channel")
+	}
+	chaincodeName, tokenName, err := getSigningDomain(stub)
+	if err != nil {
+		return "", nil, nil, signedPayloadError("failed to get the signing domain, err: " + err.Error())
+	}
+	if payload.Chaincode != chaincodeName || payload.Token != tokenName {
+		return "", nil, nil, signedPayloadError("payload is signed for another chaincode or token")
+	}
+	if getTxUnixTime(stub) > payload.Expiry {
+		return "", nil, nil, signedPayloadError("payload is expired")
+	}
+
+	// check nonce
+	if payload.Nonce != getNonce(stub, payload.Signer) {
+		return "", nil, nil, signedPayloadError("invalid nonce")
+	}
+
+	// verify signature
+	publicKey := getPublicKey(stub, payload.Signer)
+	if publicKey == nil {
+		return "", nil, nil, signedPayloadError("signer has no registered public key")
+	}
+	if !verifySignature(publicKey, []byte(payloadJSON), signature) {
+		return "", nil, nil, signedPayloadError("invalid signature")
+	}
+
+	// increase nonce
+	increaseNonce(stub, payload.Signer)
+
+	// insert the signer as the acting account
+	signedStub := &actingStub{stub, payload.Signer}
+	if actingIndex < 0 {
+		return payload.Function, payload.Args, signedStub, nil
+	}
+
+	functionParams := append([]string{}, payload.Args[:actingIndex]...)
+	functionParams = append(functionParams, payload.Signer)
+	functionParams = append(functionParams, payload.Args[actingIndex:]...)
+
+	return payload.Function, functionParams, signedStub, nil
+}
+
+// signedPayloadError makes the error of the signed payload
+func signedPayloadError(message string) error {
+	return &model.CustomError{
+		ErrorType:  model.VerifyErrorType,
+		TargetName: "signedPayload",
+		Message:    message}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strings"
+	"testing"
+)
+
+// parseSignedPayload parses the signed payload, the dispatch is tested through the chaincode.
+// Returns the acting account, the function and its params separated by spaces.
+func (l *testLedger) parseSignedPayload(stub fabric.Stub, params []string) fabric.Response {
+	fcn, signedParams, signedStub, err := l.cc.ParseSignedPayload(stub, params)
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	actingAddress, err := getCallerAddress(signedStub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	return fabric.Success([]byte(strings.Join(append([]string{actingAddress, fcn}, signedParams...), " ")))
+}
+
+func TestSignedPayloadDomain(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	key := newSigningKey(t)
+	l.mustInvoke("alice", l.cc.RegisterKey, key.publicKeyPEM())
+
+	signedPayload := func(chaincode, token string, nonce int) []string {
+		payload := model.SignedPayload{
+			Channel:   "mychannel",
+			Chaincode: chaincode,
+			Token:     token,
+			Signer:    "alice",
+			Function:  "transfer",
+			Args:      []string{"bob", "10"},
+			Nonce:     nonce,
+			Expiry:    l.now + 3600}
+		payloadBytes, _ := json.Marshal(payload)
+
+		return []string{string(payloadBytes), key.sign(payload)}
+	}
+
+	// the payloads of the other chaincodes and tokens cannot be replayed
+	for _, test := range []struct{ chaincode, token string }{
+		{"other", "token"},
+		{"erc20", "other"},
+		{"", ""},
+	} {
+		l.mustFail("relayer", "payload is signed for another chaincode or token", l.parseSignedPayload, signedPayload(test.chaincode, test.token, 0)...)
+	}
+
+	if parsed := l.mustInvoke("relayer", l.parseSignedPayload, signedPayload("erc20", "token", 0)...); parsed != "alice transfer alice bob 10" {
+		t.Fatalf("expected the transfer of alice, got %s", parsed)
+	}
+	l.mustFail("relayer", "invalid nonce", l.parseSignedPayload, signedPayload("erc20", "token", 0)...)
+	l.mustInvoke("relayer", l.parseSignedPayload, signedPayload("erc20", "token", 1)...)
+}
+
+func TestSignedPayloadActsForSigner(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	key := newSigningKey(t)
+	l.mustInvoke("alice", l.cc.RegisterKey, key.publicKeyPEM())
+
+	payload := model.SignedPayload{
+		Channel:   "mychannel",
+		Chaincode: "erc20",
+		Token:     "token",
+		Signer:    "alice",
+		Function:  "delegate",
+		Args:      []string{"bob"},
+		Expiry:    l.now + 3600}
+	payloadBytes, _ := json.Marshal(payload)
+
+	// the caller of delegate is the signer, not the relayer
+	if parsed := l.mustInvoke("relayer", l.parseSignedPayload, string(payloadBytes), key.sign(payload)); parsed != "alice delegate bob" {
+		t.Fatalf("expected the delegate of alice, got %s", parsed)
+	}
+}
+
+func TestSignedPayloadActingAccount(t *testing.T) {
+	l := newTokenLedger(t, "alice", 1000)
+	key := newSigningKey(t)
+	l.mustInvoke("alice", l.cc.RegisterKey, key.publicKeyPEM())
+
+	// the signer is inserted at the acting index of every whitelisted function
+	tests := map[string]string{
+		"transfer":          "alice transfer alice a b",
+		"approve":           "alice approve alice a b",
+		"approveWithExpiry": "alice approveWithExpiry alice a b",
+		"increaseAllowance": "alice increaseAllowance alice a b",
+		"decreaseAllowance": "alice decreaseAllowance alice a b",
+		"transferAndCall":   "alice transferAndCall alice a b",
+		"approveAndCall":    "alice approveAndCall alice a b",
+		"transferFrom":      "alice transferFrom a b",
+		"burn":              "alice burn a b",
+		"delegate":          "alice delegate a b",
+		"castVote":          "alice castVote a b",
+	}
+	if len(tests) != len(signedFunctions) {
+		t.Fatalf("expected a test of each of the %d signed functions, got %d", len(signedFunctions), len(tests))
+	}
+
+	nonce := 0
+	for function, expected := range tests {
+		payload := model.SignedPayload{
+			Channel:   "mychannel",
+			Chaincode: "erc20",
+			Token:     "token",
+			Signer:    "alice",
+			Function:  function,
+			Args:      []string{"a", "b"},
+			Nonce:     nonce,
+			Expiry:    l.now + 3600}
+		payloadBytes, _ := json.Marshal(payload)
+
+		if parsed := l.mustInvoke("relayer", l.parseSignedPayload, string(payloadBytes), key.sign(payload)); parsed != expected {
+			t.Fatalf("expected %q, got %q", expected, parsed)
+		}
+		nonce++
+	}
+}
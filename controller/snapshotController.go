@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"fmt"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+)
+
+// Snapshot is invoke function that takes a snapshot of the balances and total supply, /
+// the caller must be the owner of the token /
+// params - tokenName.
+// Returns the id of the new snapshot.
+func (cc *Controller) Snapshot(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	tokenName := params[0]
+
+	ownerAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check the caller is the owner of the token
+	erc20 := cc.getMetadata(stub, tokenName)
+	if erc20 == nil {
+		return fabric.Error("erc20 metadata does not exist in the ledger")
+	}
+	if erc20.Owner != ownerAddress {
+		return fabric.Error("only the owner can take a snapshot")
+	}
+
+	// increase snapshot id
+	snapshotID := takeSnapshot(stub)
+
+	// emit snapshot event
+	err = stub.SetEvent("snapshotEvent", []byte(strconv.Itoa(snapshotID)))
+	CheckErr(err, `failed to stub.SetEvent("snapshotEvent", snapshotID)`)
+
+	return fabric.Success([]byte(strconv.Itoa(snapshotID)))
+}
+
+// BalanceOfAt is query function
+// params - address, snapshot id
+// Returns the amount of tokens owned by the address at the time of the snapshot
+func (cc *Controller) BalanceOfAt(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	address := params[0]
+
+	snapshotID, err := checkSnapshotID(stub, params[1])
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// find the first value recorded at or after the snapshot
+	balance, found := snapshotValueAt(stub, "balanceSnapshot", []string{address}, snapshotID)
+	if !found {
+		balanceInt, _, err := cc.getStore(stub).GetBalance(address)
+		CheckErr(err, "failed to balances.GetBalance(address)")
+		balance = strconv.Itoa(balanceInt)
+	}
+
+	return fabric.Success([]byte(balance))
+}
+
+// TotalSupplyAt is query function
+// params - tokenName, snapshot id
+// Returns the amount of token in the ledger at the time of the snapshot
+func (cc *Controller) TotalSupplyAt(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	tokenName := params[0]
+
+	snapshotID, err := checkSnapshotID(stub, params[1])
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// find the first value recorded at or after the snapshot
+	totalSupply, found := snapshotValueAt(stub, "supplySnapshot", []string{tokenName}, snapshotID)
+	if !found {
+		erc20 := cc.getMetadata(stub, tokenName)
+		if erc20 == nil {
+			return fabric.Error("erc20 metadata does not exist in the ledger")
+		}
+		totalSupply = strconv.FormatUint(erc20.TotalSupply, 10)
+	}
+
+	return fabric.Success([]byte(totalSupply))
+}
+
+// currentSnapshotID gets the id of the latest snapshot, 0 if no snapshot was taken
+func currentSnapshotID(stub fabric.Stub) int {
+	snapshotIDKey, err := stub.CreateCompositeKey("snapshotID", []string{})
+	CheckErr(err, "failed to make a composite key for snapshotID")
+
+	snapshotIDBytes, err := stub.GetState(snapshotIDKey)
+	CheckErr(err, "failed to stub.GetState(snapshotIDKey)")
+	if snapshotIDBytes == nil {
+		return 0
+	}
+
+	snapshotID, err := strconv.Atoi(string(snapshotIDBytes))
+	CheckErr(err, "failed to strconv.Atoi(string(snapshotIDBytes))")
+
+	return snapshotID
+}
+
+// takeSnapshot increases the snapshot id
+// Returns the id of the new snapshot.
+func takeSnapshot(stub fabric.Stub) int {
+	snapshotID := currentSnapshotID(stub) + 1
+
+	snapshotIDKey, err := stub.CreateCompositeKey("snapshotID", []string{})
+	CheckErr(err, "failed to make a composite key for snapshotID")
+
+	err = stub.PutState(snapshotIDKey, []byte(strconv.Itoa(snapshotID)))
+	CheckErr(err, "failed to stub.PutState(snapshotIDKey, snapshotID)")
+
+	return snapshotID
+}
+
+// checkSnapshotID converts the snapshot id and checks it was already taken
+func checkSnapshotID(stub fabric.Stub, value string) (int, error) {
+	snapshotID, err := strconv.Atoi(value)
+	if err != nil || snapshotID <= 0 {
+		return 0, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "snapshotID",
+			Message:    "must be more than zero"}
+	}
+
+	if snapshotID > currentSnapshotID(stub) {
+		return 0, fmt.Errorf("snapshot %d does not exist", snapshotID)
+	}
+
+	return snapshotID, nil
+}
+
+// updateBalanceSnapshot records the balance of the address before it is changed /
+// for the current snapshot. Must be called before every balance update.
+func updateBalanceSnapshot(stub fabric.Stub, address string, balance int) {
+	updateSnapshotValue(stub, "balanceSnapshot", []string{address}, strconv.Itoa(balance))
+}
+
+// updateSupplySnapshot records the total supply before it is changed /
+// for the current snapshot. Must be called before every total supply update.
+func updateSupplySnapshot(stub fabric.Stub, erc20 *model.ERC20Metadata) {
+	updateSnapshotValue(stub, "supplySnapshot", []string{erc20.Name}, strconv.FormatUint(erc20.TotalSupply, 10))
+}
+
+// updateSnapshotValue saves the value only once per snapshot, /
+// so the first (pre-change) value of the snapshot period is kept.
+func updateSnapshotValue(stub fabric.Stub, objectType string, attributes []string, value string) {
+	snapshotID := currentSnapshotID(stub)
+	if snapshotID == 0 {
+		return
+	}
+
+	snapshotKey, err := stub.CreateCompositeKey(objectType, append(attributes, formatSnapshotID(snapshotID)))
+	CheckErr(err, "failed to make a composite key for "+objectType)
+
+	valueBytes, err := stub.GetState(snapshotKey)
+	CheckErr(err, "failed to stub.GetState(snapshotKey)")
+	if valueBytes != nil {
+		return
+	}
+
+	err = stub.PutState(snapshotKey, []byte(value))
+	CheckErr(err, "failed to stub.PutState(snapshotKey, value)")
+}
+
+// snapshotValueAt finds the first value recorded at or after the snapshot id
+func snapshotValueAt(stub fabric.Stub, objectType string, attributes []string, snapshotID int) (string, bool) {
+	snapshotIter, err := stub.GetStateByPartialCompositeKey(objectType, attributes)
+	CheckErr(err, "failed to stub.GetStateByPartialCompositeKey(objectType, attributes)")
+	defer snapshotIter.Close()
+
+	for snapshotIter.HasNext() {
+		snapshotKeyValue, err := snapshotIter.Next()
+		CheckErr(err, "failed to snapshotIter.Next()")
+
+		_, keys, err := stub.SplitCompositeKey(snapshotKeyValue.GetKey())
+		CheckErr(err, "failed to stub.SplitCompositeKey(snapshotKeyValue.GetKey())")
+
+		recordedID, err := strconv.Atoi(keys[len(keys)-1])
+		CheckErr(err, "failed to strconv.Atoi(recordedID)")
+
+		if recordedID >= snapshotID {
+			return string(snapshotKeyValue.GetValue()), true
+		}
+	}
+
+	return "", false
+}
+
+// formatSnapshotID pads the snapshot id so that the composite keys are sorted by id
+func formatSnapshotID(snapshotID int) string {
+	return fmt.Sprintf("%020d", snapshotID)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMintAndBurnAsCaller(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+
+	// the owner is the caller, not a param
+	l.mustFail("alice", "only the owner can mint", l.cc.Mint, "token", "alice", "500")
+	l.mustFail("alice", "the number of params must be three", l.cc.Mint, "token", "owner", "alice", "500")
+	l.mustInvoke("owner", l.cc.Mint, "token", "alice", "500")
+
+	// the burner is the caller, not a param
+	l.mustFail("alice", "the number of params must be two", l.cc.Burn, "token", "owner", "100")
+	l.mustFail("bob", "caller's balance does not exist", l.cc.Burn, "token", "100")
+	l.mustInvoke("alice", l.cc.Burn, "token", "100")
+
+	l.expectBalances(map[string]int{"owner": 1000, "alice": 400})
+	if supply := l.mustInvoke("", l.cc.TotalSupply, "token"); supply != "1400" {
+		t.Fatalf("expected the total supply 1400, got %s", supply)
+	}
+}
+
+func TestMintOverflow(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+
+	l.mustFail("owner", "total supply overflows", l.cc.Mint, "token", "alice", strconv.Itoa(maxAmount-999))
+	l.mustInvoke("owner", l.cc.Mint, "token", "alice", strconv.Itoa(maxAmount-1000))
+	l.mustFail("owner", "total supply overflows", l.cc.Mint, "token", "alice", "1")
+
+	l.expectBalances(map[string]int{"owner": 1000, "alice": maxAmount - 1000})
+	if supply := l.mustInvoke("", l.cc.TotalSupply, "token"); supply != strconv.Itoa(maxAmount) {
+		t.Fatalf("expected the total supply %d, got %s", maxAmount, supply)
+	}
+}
+
+func TestSnapshotBalances(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+
+	l.mustFail("alice", "only the owner can take a snapshot", l.cc.Snapshot, "token")
+	l.mustFail("owner", "the number of params must be one", l.cc.Snapshot, "token", "owner")
+
+	snapshotID := l.mustInvoke("owner", l.cc.Snapshot, "token")
+	if snapshotID != "1" {
+		t.Fatalf("expected the snapshot 1, got %s", snapshotID)
+	}
+
+	// the changes after the snapshot do not change the values at the snapshot
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "300")
+	l.mustInvoke("owner", l.cc.Mint, "token", "alice", "200")
+	l.mustInvoke("alice", l.cc.Burn, "token", "100")
+
+	for address, expected := range map[string]string{"owner": "1000", "alice": "0"} {
+		if balance := l.mustInvoke("", l.cc.BalanceOfAt, address, snapshotID); balance != expected {
+			t.Fatalf("balance of %s at the snapshot: expected %s, got %s", address, expected, balance)
+		}
+	}
+	if supply := l.mustInvoke("", l.cc.TotalSupplyAt, "token", snapshotID); supply != "1000" {
+		t.Fatalf("expected the total supply 1000 at the snapshot, got %s", supply)
+	}
+	l.expectBalances(map[string]int{"owner": 700, "alice": 400})
+}
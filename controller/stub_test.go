@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"hypherledgertest2/fabric"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+// testLedger is the committed state of the controller tests, /
+// the transactions run on txStub and only the successful ones are committed as on a peer.
+type testLedger struct {
+	t          *testing.T
+	cc         *Controller
+	state      map[string][]byte
+	private    map[string][]byte
+	now        int64
+	txNum      int
+	event      *testEvent
+	chaincodes map[string]func(args []string) fabric.Response
+}
+
+// testEvent is the event of the last committed transaction
+type testEvent struct {
+	name    string
+	payload []byte
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	return &testLedger{
+		t:          t,
+		cc:         NewController(),
+		state:      map[string][]byte{},
+		private:    map[string][]byte{},
+		now:        1893456000,
+		chaincodes: map[string]func(args []string) fabric.Response{},
+	}
+}
+
+// newTokenLedger deploys the token with the owner's balance
+func newTokenLedger(t *testing.T, owner string, supply int) *testLedger {
+	l := newTestLedger(t)
+	l.mustInvoke("", l.cc.Init, "token", "TKN", owner, strconv.Itoa(supply))
+
+	return l
+}
+
+// invokeTransient runs the function as the caller with the transient data, /
+// and commits its writes and its event if it succeeds
+func (l *testLedger) invokeTransient(caller string, transient map[string][]byte, fn func(fabric.Stub, []string) fabric.Response, params ...string) fabric.Response {
+	l.txNum++
+	stub := &txStub{
+		ledger:    l,
+		txID:      "tx" + strconv.Itoa(l.txNum),
+		writes:    map[string][]byte{},
+		private:   map[string][]byte{},
+		transient: transient,
+	}
+
+	res := fn(&actingStub{stub, caller}, params)
+	if res.Status < 400 {
+		stub.commit()
+	}
+
+	return res
+}
+
+func (l *testLedger) invoke(caller string, fn func(fabric.Stub, []string) fabric.Response, params ...string) fabric.Response {
+	return l.invokeTransient(caller, nil, fn, params...)
+}
+
+func (l *testLedger) mustInvoke(caller string, fn func(fabric.Stub, []string) fabric.Response, params ...string) string {
+	l.t.Helper()
+
+	res := l.invoke(caller, fn, params...)
+	if res.Status >= 400 {
+		l.t.Fatalf("%v failed: %s", params, res.Message)
+	}
+
+	return string(res.Payload)
+}
+
+// mustFail invokes the function and checks it fails with the message containing the substring
+func (l *testLedger) mustFail(caller string, message string, fn func(fabric.Stub, []string) fabric.Response, params ...string) {
+	l.t.Helper()
+
+	res := l.invoke(caller, fn, params...)
+	if res.Status < 400 {
+		l.t.Fatalf("%v must fail", params)
+	}
+	if !strings.Contains(res.Message, message) {
+		l.t.Fatalf("%v: expected the message containing %q, got %q", params, message, res.Message)
+	}
+}
+
+// balance gets the committed balance of the address
+func (l *testLedger) balance(address string) int {
+	balance, _ := strconv.Atoi(string(l.state[address]))
+
+	return balance
+}
+
+func (l *testLedger) expectBalances(balances map[string]int) {
+	l.t.Helper()
+
+	for address, expected := range balances {
+		if balance := l.balance(address); balance != expected {
+			l.t.Fatalf("balance of %s: expected %d, got %d", address, expected, balance)
+		}
+	}
+}
+
+// txStub is the stub of a transaction on the testLedger. /
+// As on a peer, the reads get the committed state and do not see the writes of the transaction, /
+// and only the last event of the transaction is kept.
+type txStub struct {
+	fabric.Stub
+	ledger    *testLedger
+	txID      string
+	writes    map[string][]byte
+	private   map[string][]byte
+	transient map[string][]byte
+	event     *testEvent
+}
+
+func (s *txStub) commit() {
+	for key, value := range s.writes {
+		if value == nil {
+			delete(s.ledger.state, key)
+			continue
+		}
+		s.ledger.state[key] = value
+	}
+	for key, value := range s.private {
+		s.ledger.private[key] = value
+	}
+	s.ledger.event = s.event
+}
+
+func (s *txStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *txStub) GetChannelID() string {
+	return "mychannel"
+}
+
+// GetSignedProposal gets the proposal invoking the chaincode erc20
+func (s *txStub) GetSignedProposal() (*fabric.SignedProposal, error) {
+	return fabric.NewSignedProposal("erc20")
+}
+
+func (s *txStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return &timestamp.Timestamp{Seconds: s.ledger.now}, nil
+}
+
+func (s *txStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *txStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return "\x00" + objectType + "\x00" + strings.Join(append(attributes, ""), "\x00"), nil
+}
+
+func (s *txStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	parts := strings.Split(strings.Trim(compositeKey, "\x00"), "\x00")
+
+	return parts[0], parts[1:], nil
+}
+
+func (s *txStub) GetState(key string) ([]byte, error) {
+	return s.ledger.state[key], nil
+}
+
+func (s *txStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+
+	return nil
+}
+
+func (s *txStub) DelState(key string) error {
+	s.writes[key] = nil
+
+	return nil
+}
+
+func (s *txStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.ledger.private[collection+"/"+key], nil
+}
+
+func (s *txStub) PutPrivateData(collection, key string, value []byte) error {
+	s.private[collection+"/"+key] = value
+
+	return nil
+}
+
+func (s *txStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (fabric.StateQueryIterator, error) {
+	prefix, _ := s.CreateCompositeKey(objectType, attributes)
+
+	return s.query(func(key string) bool { return strings.HasPrefix(key, prefix) }), nil
+}
+
+func (s *txStub) GetStateByRange(startKey, endKey string) (fabric.StateQueryIterator, error) {
+	return s.query(func(key string) bool { return key >= startKey && key < endKey }), nil
+}
+
+// query gets the committed entries of the matching keys in order
+func (s *txStub) query(match func(key string) bool) *testIterator {
+	iter := &testIterator{}
+	for key, value := range s.ledger.state {
+		if match(key) {
+			iter.entries = append(iter.entries, &fabric.KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(iter.entries, func(i, j int) bool { return iter.entries[i].Key < iter.entries[j].Key })
+
+	return iter
+}
+
+func (s *txStub) SetEvent(name string, payload []byte) error {
+	s.event = &testEvent{name, payload}
+
+	return nil
+}
+
+func (s *txStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) fabric.Response {
+	chaincode, ok := s.ledger.chaincodes[chaincodeName]
+	if !ok {
+		return fabric.Error("chaincode " + chaincodeName + " is not installed")
+	}
+
+	params := make([]string, len(args))
+	for i, arg := range args {
+		params[i] = string(arg)
+	}
+
+	return chaincode(params)
+}
+
+// testIterator is the iterator of the committed entries
+type testIterator struct {
+	entries []*fabric.KV
+}
+
+func (i *testIterator) HasNext() bool {
+	return len(i.entries) > 0
+}
+
+func (i *testIterator) Next() (*fabric.KV, error) {
+	entry := i.entries[0]
+	i.entries = i.entries[1:]
+
+	return entry, nil
+}
+
+func (i *testIterator) Close() error {
+	return nil
+}
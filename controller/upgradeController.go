@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+)
+
+// migration is the function that converts the state from the previous schema version
+type migration struct {
+	name    string
+	migrate func(stub fabric.Stub) error
+}
+
+// migrations are the registered migrations in order, /
+// migrations[i] upgrades the state from the schema version i to i+1.
+// The state saved before the schema version was recorded is version 0.
+var migrations = []migration{
+	{"allowanceRecords", migrateAllowanceRecords},
+}
+
+// LatestSchemaVersion is the schema version of the state written by this chaincode
+var LatestSchemaVersion = len(migrations)
+
+// UpgradeStatus is query function
+// Returns the schema version of the state and the migrations not run yet
+func (cc *Controller) UpgradeStatus(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 0 {
+		return fabric.Error("the number of params must be zero")
+	}
+
+	status := model.UpgradeStatus{LatestSchemaVersion: LatestSchemaVersion, PendingMigrations: []string{}}
+	schemaVersion := getSchemaVersion(stub)
+	if schemaVersion == nil {
+		schemaVersion = getLegacySchemaVersion(stub)
+	}
+	if schemaVersion != nil {
+		status.SchemaVersion = schemaVersion.Version
+		status.TokenName = schemaVersion.TokenName
+		status.LastUpgradeTxID = schemaVersion.TxID
+		status.LastUpgradedAt = schemaVersion.UpgradedAt
+	}
+
+	for version := status.SchemaVersion; version < LatestSchemaVersion; version++ {
+		status.PendingMigrations = append(status.PendingMigrations, migrations[version].name)
+	}
+
+	statusBytes, err := json.Marshal(status)
+	CheckErr(err, "failed to json.Marshal(status)")
+
+	return fabric.Success(statusBytes)
+}
+
+// upgrade keeps the state of the deployed token and runs the migrations of the newer schema versions
+func upgrade(stub fabric.Stub, tokenName string, schemaVersion *model.SchemaVersion) fabric.Response {
+	// the token of the chaincode cannot be changed by the upgrade
+	if schemaVersion.TokenName != tokenName {
+		return fabric.Error("the chaincode is deployed for the token " + schemaVersion.TokenName)
+	}
+
+	// the state cannot be downgraded
+	if schemaVersion.Version > LatestSchemaVersion {
+		return fabric.Error("the schema version " + strconv.Itoa(schemaVersion.Version) +
+			" is newer than the chaincode, latest: " + strconv.Itoa(LatestSchemaVersion))
+	}
+
+	// run the migrations in order
+	fromVersion := schemaVersion.Version
+	for version := fromVersion; version < LatestSchemaVersion; version++ {
+		err := migrations[version].migrate(stub)
+		if err != nil {
+			return fabric.Error("failed to run the migration " + migrations[version].name + ", err: " + err.Error())
+		}
+	}
+
+	if fromVersion == LatestSchemaVersion {
+		return fabric.Success([]byte("schema is up to date"))
+	}
+
+	putSchemaVersion(stub, tokenName)
+
+	return fabric.Success([]byte("upgraded schema from version " + strconv.Itoa(fromVersion) +
+		" to " + strconv.Itoa(LatestSchemaVersion)))
+}
+
+// getLegacySchemaVersion gets the version 0 of the state saved before the schema version was recorded, /
+// nil if there is no legacy state. The metadata is saved under the token name among the balances, /
+// so the simple keys are scanned instead of trusting the token name of the upgrade.
+func getLegacySchemaVersion(stub fabric.Stub) *model.SchemaVersion {
+	simpleKeyIter, err := stub.GetStateByRange(firstSimpleKey, lastSimpleKey)
+	CheckErr(err, "failed to stub.GetStateByRange(firstSimpleKey, lastSimpleKey)")
+	defer simpleKeyIter.Close()
+
+	for simpleKeyIter.HasNext() {
+		simpleKeyValue, err := simpleKeyIter.Next()
+		CheckErr(err, "failed to simpleKeyIter.Next()")
+
+		// skip the balances
+		if _, err := strconv.Atoi(string(simpleKeyValue.GetValue())); err == nil {
+			continue
+		}
+
+		erc20 := model.ERC20Metadata{}
+		err = json.Unmarshal(simpleKeyValue.GetValue(), &erc20)
+		if err == nil && erc20.Name == simpleKeyValue.GetKey() {
+			return &model.SchemaVersion{Version: 0, TokenName: erc20.Name}
+		}
+	}
+
+	return nil
+}
+
+// getSchemaVersion gets the schema version record, nil if it does not exist
+func getSchemaVersion(stub fabric.Stub) *model.SchemaVersion {
+	schemaVersionKey, err := stub.CreateCompositeKey("schemaVersion", []string{})
+	CheckErr(err, "failed to make a composite key for schemaVersion")
+
+	schemaVersionBytes, err := stub.GetState(schemaVersionKey)
+	CheckErr(err, "failed to stub.GetState(schemaVersionKey)")
+	if schemaVersionBytes == nil {
+		return nil
+	}
+
+	schemaVersion := model.SchemaVersion{}
+	err = json.Unmarshal(schemaVersionBytes, &schemaVersion)
+	CheckErr(err, "failed to json.Unmarshal(schemaVersionBytes, &schemaVersion)")
+
+	return &schemaVersion
+}
+
+// putSchemaVersion records the latest schema version for the token
+func putSchemaVersion(stub fabric.Stub, tokenName string) {
+	schemaVersionKey, err := stub.CreateCompositeKey("schemaVersion", []string{})
+	CheckErr(err, "failed to make a composite key for schemaVersion")
+
+	schemaVersion := model.SchemaVersion{
+		Version:    LatestSchemaVersion,
+		TokenName:  tokenName,
+		TxID:       stub.GetTxID(),
+		UpgradedAt: getTxUnixTime(stub)}
+
+	schemaVersionBytes, err := json.Marshal(schemaVersion)
+	CheckErr(err, "failed to json.Marshal(schemaVersion)")
+
+	err = stub.PutState(schemaVersionKey, schemaVersionBytes)
+	CheckErr(err, "failed to stub.PutState(schemaVersionKey, schemaVersionBytes)")
+}
+
+// migrateAllowanceRecords converts the plain allowance amounts to the allowance records
+func migrateAllowanceRecords(stub fabric.Stub) error {
+	approvalIter, err := stub.GetStateByPartialCompositeKey("approval", []string{})
+	if err != nil {
+		return err
+	}
+	defer approvalIter.Close()
+
+	for approvalIter.HasNext() {
+		approvalKeyValue, err := approvalIter.Next()
+		if err != nil {
+			return err
+		}
+
+		// skip the allowances saved as the records
+		amountInt, err := strconv.Atoi(string(approvalKeyValue.GetValue()))
+		if err != nil {
+			continue
+		}
+
+		allowanceBytes, err := json.Marshal(model.Allowance{Amount: amountInt})
+		if err != nil {
+			return err
+		}
+
+		err = stub.PutState(approvalKeyValue.GetKey(), allowanceBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
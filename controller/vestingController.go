@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"hypherledgertest2/util"
+	"math/big"
+	"strconv"
+)
+
+// VestingEscrowAddress is the address holding the tokens locked in vesting schedules
+const VestingEscrowAddress = "vestingEscrow"
+
+// CreateVesting is invoke function that escrows amount token of the grantor, who is the caller, /
+// and releases them to the beneficiary with a cliff and linear vesting /
+// params - beneficiary's address, total, start(unix seconds), /
+// cliff(seconds from start), duration(seconds from start), revocable.
+// Returns the id of the vesting schedule.
+func (cc *Controller) CreateVesting(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is six
+	if len(params) != 6 {
+		return fabric.Error("the number of params must be six")
+	}
+
+	beneficiaryAddress, total := params[0], params[1]
+
+	// the grantor is the caller
+	grantorAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	// check total is integer & positive
+	totalInt, err := util.ConverToPositive(total, "vestingTotal")
+	if err != nil {
+		return fabric.Error(err.Error())
+	}
+
+	// check start, cliff, duration are integer
+	start, err := strconv.ParseInt(params[2], 10, 64)
+	if err != nil {
+		return fabric.Error("start must be a unix timestamp")
+	}
+
+	cliff, err := strconv.ParseInt(params[3], 10, 64)
+	if err != nil || cliff < 0 {
+		return fabric.Error("cliff must be a number of seconds and cannot be negative")
+	}
+
+	duration, err := strconv.ParseInt(params[4], 10, 64)
+	if err != nil || duration <= 0 {
+		return fabric.Error("duration must be a number of seconds and must be more than zero")
+	}
+
+	if cliff > duration {
+		return fabric.Error("cliff cannot be longer than duration")
+	}
+
+	revocable, err := strconv.ParseBool(params[5])
+	if err != nil {
+		return fabric.Error("revocable must be true or false")
+	}
+
+	if len(beneficiaryAddress) == 0 {
+		return fabric.Error("beneficiary cannot be empty")
+	}
+
+	// escrow grantor's tokens
+	transferResponse := cc.Transfer(stub, []string{grantorAddress, VestingEscrowAddress, total})
+	if transferResponse.Status == SpendingLimitExceededStatus {
+		return transferResponse
+	}
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.Transfer([]string{grantorAddress, VestingEscrowAddress, total}), err: ` + transferResponse.GetMessage())
+	}
+
+	// save vesting schedule
+	vesting := model.NewVesting(stub.GetTxID(), grantorAddress, beneficiaryAddress, totalInt, start, cliff, duration, revocable)
+	putVesting(stub, vesting)
+
+	return fabric.Success([]byte(vesting.ID))
+}
+
+// Release is invoke function that transfers the vested-so-far amount /
+// from the escrow to the beneficiary /
+// params - vesting id.
+func (cc *Controller) Release(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	vesting := getVesting(stub, params[0])
+	if vesting == nil {
+		return fabric.Error("vesting does not exist in the ledger")
+	}
+
+	// calculate releasable amount
+	releasable := vestedAmount(vesting, getTxUnixTime(stub)) - vesting.Released
+	if releasable <= 0 {
+		return fabric.Error("there is no releasable amount")
+	}
+
+	// transfer from escrow to beneficiary
+	transferResponse := cc.transfer(stub, []string{VestingEscrowAddress, vesting.Beneficiary, strconv.Itoa(releasable)})
+	if transferResponse.Status >= 400 {
+		return fabric.Error(`failed to cc.transfer([]string{VestingEscrowAddress, beneficiary, releasable}), err: ` + transferResponse.GetMessage())
+	}
+
+	// save released amount
+	vesting.Released += releasable
+	putVesting(stub, vesting)
+
+	return fabric.Success([]byte(strconv.Itoa(releasable)))
+}
+
+// Revoke is invoke function that stops a revocable vesting schedule /
+// and refunds the unvested amount to the grantor, who must be the caller /
+// params - vesting id.
+func (cc *Controller) Revoke(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	grantorAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	vesting := getVesting(stub, params[0])
+	if vesting == nil {
+		return fabric.Error("vesting does not exist in the ledger")
+	}
+
+	// check the vesting can be revoked by the caller
+	if vesting.Grantor != grantorAddress {
+		return fabric.Error("only the grantor can revoke the vesting")
+	}
+	if !vesting.Revocable {
+		return fabric.Error("vesting is not revocable")
+	}
+	if vesting.Revoked {
+		return fabric.Error("vesting is already revoked")
+	}
+
+	// refund unvested amount to grantor
+	vested := vestedAmount(vesting, getTxUnixTime(stub))
+	refund := vesting.Total - vested
+	if refund > 0 {
+		transferResponse := cc.transfer(stub, []string{VestingEscrowAddress, vesting.Grantor, strconv.Itoa(refund)})
+		if transferResponse.Status >= 400 {
+			return fabric.Error(`failed to cc.transfer([]string{VestingEscrowAddress, grantor, refund}), err: ` + transferResponse.GetMessage())
+		}
+	}
+
+	// the vested amount stays releasable to the beneficiary
+	vesting.Total = vested
+	vesting.Revoked = true
+	putVesting(stub, vesting)
+
+	return fabric.Success([]byte(strconv.Itoa(refund)))
+}
+
+// VestedAmount is query function
+// params - vesting id
+// Returns the amount of token vested so far.
+func (cc *Controller) VestedAmount(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	vesting := getVesting(stub, params[0])
+	if vesting == nil {
+		return fabric.Error("vesting does not exist in the ledger")
+	}
+
+	vested := vestedAmount(vesting, getTxUnixTime(stub))
+
+	return fabric.Success([]byte(strconv.Itoa(vested)))
+}
+
+// ReleasableAmount is query function
+// params - vesting id
+// Returns the amount of token vested but not released yet.
+func (cc *Controller) ReleasableAmount(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	vesting := getVesting(stub, params[0])
+	if vesting == nil {
+		return fabric.Error("vesting does not exist in the ledger")
+	}
+
+	releasable := vestedAmount(vesting, getTxUnixTime(stub)) - vesting.Released
+
+	return fabric.Success([]byte(strconv.Itoa(releasable)))
+}
+
+// GetVesting is query function
+// params - vesting id
+// Returns the vesting schedule.
+func (cc *Controller) GetVesting(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	vesting := getVesting(stub, params[0])
+	if vesting == nil {
+		return fabric.Error("vesting does not exist in the ledger")
+	}
+
+	vestingBytes, err := json.Marshal(vesting)
+	CheckErr(err, "failed to json.Marshal(vesting)")
+
+	return fabric.Success(vestingBytes)
+}
+
+// vestedAmount calculates the amount vested at the given time
+func vestedAmount(vesting *model.Vesting, now int64) int {
+	if vesting.Revoked {
+		return vesting.Total
+	}
+
+	elapsed := now - vesting.Start
+	if elapsed < vesting.Cliff {
+		return 0
+	}
+	if elapsed >= vesting.Duration {
+		return vesting.Total
+	}
+
+	// total * elapsed / duration without overflow
+	vested := new(big.Int).Mul(big.NewInt(int64(vesting.Total)), big.NewInt(elapsed))
+	vested.Quo(vested, big.NewInt(vesting.Duration))
+
+	return int(vested.Int64())
+}
+
+// getVesting gets the vesting schedule from the ledger, nil if it does not exist
+func getVesting(stub fabric.Stub, vestingID string) *model.Vesting {
+	vestingKey, err := stub.CreateCompositeKey("vesting", []string{vestingID})
+	CheckErr(err, "failed to make a composite key for vesting")
+
+	vestingBytes, err := stub.GetState(vestingKey)
+	CheckErr(err, "failed to stub.GetState(vestingKey)")
+	if vestingBytes == nil {
+		return nil
+	}
+
+	vesting := model.Vesting{}
+	err = json.Unmarshal(vestingBytes, &vesting)
+	CheckErr(err, "failed to json.Unmarshal(vestingBytes, &vesting)")
+
+	return &vesting
+}
+
+// putVesting saves the vesting schedule to the ledger
+func putVesting(stub fabric.Stub, vesting *model.Vesting) {
+	vestingKey, err := stub.CreateCompositeKey("vesting", []string{vesting.ID})
+	CheckErr(err, "failed to make a composite key for vesting")
+
+	vestingBytes, err := json.Marshal(vesting)
+	CheckErr(err, "failed to json.Marshal(vesting)")
+
+	err = stub.PutState(vestingKey, vestingBytes)
+	CheckErr(err, "failed to stub.PutState(vestingKey, vestingBytes)")
+}
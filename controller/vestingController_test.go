@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"encoding/json"
+	"hypherledgertest2/model"
+	"strconv"
+	"testing"
+)
+
+func TestVestedAmount(t *testing.T) {
+	vesting := model.NewVesting("vesting", "grantor", "beneficiary", 1000, 100, 10, 110, true)
+
+	for _, test := range []struct {
+		now      int64
+		expected int
+	}{
+		{50, 0},
+		{109, 0},
+		{110, 90},
+		{155, 500},
+		{210, 1000},
+		{1000, 1000},
+	} {
+		if vested := vestedAmount(vesting, test.now); vested != test.expected {
+			t.Errorf("vested at %d: expected %d, got %d", test.now, test.expected, vested)
+		}
+	}
+}
+
+func TestVestingRelease(t *testing.T) {
+	l := newTokenLedger(t, "grantor", 10000)
+	start := strconv.FormatInt(l.now, 10)
+
+	vestingID := l.mustInvoke("grantor", l.cc.CreateVesting, "alice", "1000", start, "3600", "36000", "true")
+	l.expectBalances(map[string]int{"grantor": 9000, VestingEscrowAddress: 1000})
+
+	vesting := model.Vesting{}
+	json.Unmarshal([]byte(l.mustInvoke("", l.cc.GetVesting, vestingID)), &vesting)
+	if vesting.Grantor != "grantor" || vesting.Beneficiary != "alice" || vesting.Total != 1000 {
+		t.Fatalf("expected the vesting of the caller, got %+v", vesting)
+	}
+
+	l.mustFail("", "there is no releasable amount", l.cc.Release, vestingID)
+
+	l.now += 18000
+	if released := l.mustInvoke("bob", l.cc.Release, vestingID); released != "500" {
+		t.Fatalf("expected the release of 500, got %s", released)
+	}
+	l.expectBalances(map[string]int{"alice": 500, VestingEscrowAddress: 500})
+}
+
+func TestVestingCreateAsCaller(t *testing.T) {
+	l := newTokenLedger(t, "grantor", 10000)
+	start := strconv.FormatInt(l.now, 10)
+
+	// the grantor is the caller, not a param
+	l.mustFail("alice", "caller's balance does not exist", l.cc.CreateVesting, "alice", "1000", start, "0", "100", "true")
+	l.mustFail("grantor", "the number of params must be six", l.cc.CreateVesting, "grantor", "alice", "1000", start, "0", "100", "true")
+	l.expectBalances(map[string]int{"grantor": 10000, VestingEscrowAddress: 0})
+}
+
+func TestVestingRevoke(t *testing.T) {
+	l := newTokenLedger(t, "grantor", 10000)
+	start := strconv.FormatInt(l.now, 10)
+	vestingID := l.mustInvoke("grantor", l.cc.CreateVesting, "alice", "1000", start, "0", "100", "true")
+
+	l.now += 25
+	l.mustFail("alice", "only the grantor can revoke the vesting", l.cc.Revoke, vestingID)
+	if refund := l.mustInvoke("grantor", l.cc.Revoke, vestingID); refund != "750" {
+		t.Fatalf("expected the refund of 750, got %s", refund)
+	}
+	l.mustFail("grantor", "vesting is already revoked", l.cc.Revoke, vestingID)
+
+	l.mustInvoke("alice", l.cc.Release, vestingID)
+	l.expectBalances(map[string]int{"grantor": 9750, "alice": 250, VestingEscrowAddress: 0})
+
+	irrevocableID := l.mustInvoke("grantor", l.cc.CreateVesting, "alice", "100", start, "0", "100", "false")
+	l.mustFail("grantor", "vesting is not revocable", l.cc.Revoke, irrevocableID)
+}
+
+func TestEscrowCannotTransferOrApprove(t *testing.T) {
+	l := newTokenLedger(t, "grantor", 10000)
+	start := strconv.FormatInt(l.now, 10)
+	l.mustInvoke("grantor", l.cc.CreateVesting, "alice", "1000", start, "0", "100", "true")
+
+	for _, escrowAddress := range escrowAddresses {
+		l.mustFail("", "escrow account cannot transfer", l.cc.Transfer, escrowAddress, "mallory", "1000")
+		l.mustFail("", "escrow account cannot approve", l.cc.Approve, escrowAddress, "mallory", "1000")
+		l.mustFail("", "escrow account cannot approve", l.cc.IncreaseAllowance, escrowAddress, "mallory", "1000")
+	}
+	l.expectBalances(map[string]int{VestingEscrowAddress: 1000, "mallory": 0})
+}
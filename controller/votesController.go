@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+)
+
+// Delegate is invoke function that delegates the voting power of the caller's balance /
+// to the delegatee. Delegate to oneself to vote with one's own balance /
+// params - delegatee's address.
+func (cc *Controller) Delegate(stub fabric.Stub, params []string) fabric.Response {
+	// check the number of params is one
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	delegateeAddress := params[0]
+
+	delegatorAddress, err := getCallerAddress(stub)
+	if err != nil {
+		return fabric.Error("failed to get the caller's address, err: " + err.Error())
+	}
+
+	if len(delegateeAddress) == 0 {
+		return fabric.Error("delegatee cannot be empty")
+	}
+
+	// get current delegate
+	fromDelegate := getDelegate(stub, delegatorAddress)
+	if fromDelegate == delegateeAddress {
+		return fabric.Error("delegatee is already the delegate")
+	}
+
+	// save new delegate
+	delegateKey, err := stub.CreateCompositeKey("delegate", []string{delegatorAddress})
+	CheckErr(err, "failed to make a composite key for delegate")
+
+	err = stub.PutState(delegateKey, []byte(delegateeAddress))
+	CheckErr(err, "failed to stub.PutState(delegateKey, delegateeAddress)")
+
+	// move the delegator's voting power
+	balance, _, err := cc.getStore(stub).GetBalance(delegatorAddress)
+	CheckErr(err, "failed to balances.GetBalance(delegatorAddress)")
+
+	moveDelegateVotes(stub, fromDelegate, delegateeAddress, balance)
+
+	// emit delegate changed event
+	delegateChangedEvent := model.DelegateChangedEvent{
+		Delegator:    delegatorAddress,
+		FromDelegate: fromDelegate,
+		ToDelegate:   delegateeAddress}
+
+	delegateChangedEventBytes, err := json.Marshal(delegateChangedEvent)
+	CheckErr(err, "failed to json.Marshal(delegateChangedEvent)")
+
+	err = stub.SetEvent("delegateChangedEvent", delegateChangedEventBytes)
+	CheckErr(err, `failed to stub.SetEvent("delegateChangedEvent", delegateChangedEventBytes)`)
+
+	return fabric.Success([]byte("delegate func success"))
+}
+
+// Delegates is query function
+// params - delegator's address
+// Returns the delegatee of the address, empty if not delegated
+func (cc *Controller) Delegates(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	return fabric.Success([]byte(getDelegate(stub, params[0])))
+}
+
+// GetVotes is query function
+// params - address
+// Returns the current voting power of the address
+func (cc *Controller) GetVotes(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 1 {
+		return fabric.Error("the number of params must be one")
+	}
+
+	return fabric.Success([]byte(strconv.Itoa(getVotes(stub, params[0]))))
+}
+
+// GetPastVotes is query function
+// params - address, timestamp(unix seconds)
+// Returns the voting power of the address at the end of the timestamp
+func (cc *Controller) GetPastVotes(stub fabric.Stub, params []string) fabric.Response {
+	if len(params) != 2 {
+		return fabric.Error("the number of params must be two")
+	}
+
+	address := params[0]
+
+	timestamp, err := strconv.ParseInt(params[1], 10, 64)
+	if err != nil {
+		return fabric.Error("timestamp must be a unix timestamp")
+	}
+
+	// the votes of the current timestamp can still change
+	if timestamp >= getTxUnixTime(stub) {
+		return fabric.Error("timestamp must be in the past")
+	}
+
+	return fabric.Success([]byte(strconv.Itoa(getPastVotes(stub, address, timestamp))))
+}
+
+// getDelegate gets the delegatee of the delegator, empty if not delegated
+func getDelegate(stub fabric.Stub, delegatorAddress string) string {
+	delegateKey, err := stub.CreateCompositeKey("delegate", []string{delegatorAddress})
+	CheckErr(err, "failed to make a composite key for delegate")
+
+	delegateBytes, err := stub.GetState(delegateKey)
+	CheckErr(err, "failed to stub.GetState(delegateKey)")
+
+	return string(delegateBytes)
+}
+
+// getVotes gets the current voting power of the delegatee
+func getVotes(stub fabric.Stub, delegateeAddress string) int {
+	votesKey, err := stub.CreateCompositeKey("votes", []string{delegateeAddress})
+	CheckErr(err, "failed to make a composite key for votes")
+
+	votesBytes, err := stub.GetState(votesKey)
+	CheckErr(err, "failed to stub.GetState(votesKey)")
+	if votesBytes == nil {
+		return 0
+	}
+
+	votes, err := strconv.Atoi(string(votesBytes))
+	CheckErr(err, "failed to strconv.Atoi(string(votesBytes))")
+
+	return votes
+}
+
+// getPastVotes finds the latest checkpoint at or before the timestamp
+func getPastVotes(stub fabric.Stub, delegateeAddress string, timestamp int64) int {
+	checkpointIter, err := stub.GetStateByPartialCompositeKey("votesCheckpoint", []string{delegateeAddress})
+	CheckErr(err, "failed to stub.GetStateByPartialCompositeKey(votesCheckpoint, delegateeAddress)")
+	defer checkpointIter.Close()
+
+	votes := 0
+	for checkpointIter.HasNext() {
+		checkpointKeyValue, err := checkpointIter.Next()
+		CheckErr(err, "failed to checkpointIter.Next()")
+
+		checkpoint := model.VotesCheckpoint{}
+		err = json.Unmarshal(checkpointKeyValue.GetValue(), &checkpoint)
+		CheckErr(err, "failed to json.Unmarshal(checkpointBytes, &checkpoint)")
+
+		// checkpoints are sorted by timestamp
+		if checkpoint.Timestamp > timestamp {
+			break
+		}
+		votes = checkpoint.Votes
+	}
+
+	return votes
+}
+
+// moveVotingPower moves the voting power of amount token /
+// from the delegatee of the sender to the delegatee of the recipient. /
+// Must be called in every balance-changing path, empty address for mint & burn.
+func moveVotingPower(stub fabric.Stub, senderAddress, recipientAddress string, amount int) {
+	fromDelegate, toDelegate := "", ""
+	if len(senderAddress) != 0 {
+		fromDelegate = getDelegate(stub, senderAddress)
+	}
+	if len(recipientAddress) != 0 {
+		toDelegate = getDelegate(stub, recipientAddress)
+	}
+
+	moveDelegateVotes(stub, fromDelegate, toDelegate, amount)
+}
+
+// moveVotingPowers moves the voting power of the summed balance changes of one transaction /
+// between the delegatees, writing the checkpoint of each delegatee once.
+func moveVotingPowers(stub fabric.Stub, balanceChanges map[string]int) {
+	delegateChanges := map[string]int{}
+	for address, change := range balanceChanges {
+		if delegate := getDelegate(stub, address); len(delegate) != 0 {
+			delegateChanges[delegate] += change
+		}
+	}
+
+	for _, delegate := range sortedAddresses(delegateChanges) {
+		if delegateChanges[delegate] != 0 {
+			writeVotesCheckpoint(stub, delegate, getVotes(stub, delegate)+delegateChanges[delegate])
+		}
+	}
+}
+
+// moveDelegateVotes moves votes between delegatees and writes checkpoints
+func moveDelegateVotes(stub fabric.Stub, fromDelegate, toDelegate string, amount int) {
+	if fromDelegate == toDelegate || amount == 0 {
+		return
+	}
+
+	if len(fromDelegate) != 0 {
+		writeVotesCheckpoint(stub, fromDelegate, getVotes(stub, fromDelegate)-amount)
+	}
+	if len(toDelegate) != 0 {
+		writeVotesCheckpoint(stub, toDelegate, getVotes(stub, toDelegate)+amount)
+	}
+}
+
+// writeVotesCheckpoint saves the current votes and the checkpoint of the transaction timestamp
+func writeVotesCheckpoint(stub fabric.Stub, delegateeAddress string, votes int) {
+	votesKey, err := stub.CreateCompositeKey("votes", []string{delegateeAddress})
+	CheckErr(err, "failed to make a composite key for votes")
+
+	err = stub.PutState(votesKey, []byte(strconv.Itoa(votes)))
+	CheckErr(err, "failed to stub.PutState(votesKey, votes)")
+
+	// checkpoints in the same second are overwritten by the latest one
+	timestamp := getTxUnixTime(stub)
+	checkpointKey, err := stub.CreateCompositeKey("votesCheckpoint", []string{delegateeAddress, fmt.Sprintf("%020d", timestamp)})
+	CheckErr(err, "failed to make a composite key for votesCheckpoint")
+
+	checkpointBytes, err := json.Marshal(model.VotesCheckpoint{Timestamp: timestamp, Votes: votes})
+	CheckErr(err, "failed to json.Marshal(checkpoint)")
+
+	err = stub.PutState(checkpointKey, checkpointBytes)
+	CheckErr(err, "failed to stub.PutState(checkpointKey, checkpointBytes)")
+}
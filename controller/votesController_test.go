@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"strconv"
+	"testing"
+)
+
+// expectVotes checks the current voting power of the delegatees
+func expectVotes(l *testLedger, votes map[string]string) {
+	l.t.Helper()
+
+	for delegatee, expected := range votes {
+		if actual := l.mustInvoke("", l.cc.GetVotes, delegatee); actual != expected {
+			l.t.Fatalf("votes of %s: expected %s, got %s", delegatee, expected, actual)
+		}
+	}
+}
+
+func TestDelegateAsCaller(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "300")
+
+	// the delegator is the caller, not a param
+	l.mustFail("mallory", "the number of params must be one", l.cc.Delegate, "owner", "mallory")
+	l.mustInvoke("mallory", l.cc.Delegate, "mallory")
+	if delegatee := l.mustInvoke("", l.cc.Delegates, "owner"); delegatee != "" {
+		t.Fatalf("the delegation of mallory must not change the delegatee of owner, got %s", delegatee)
+	}
+
+	l.mustFail("alice", "delegatee cannot be empty", l.cc.Delegate, "")
+	l.mustInvoke("alice", l.cc.Delegate, "alice")
+	l.mustFail("alice", "delegatee is already the delegate", l.cc.Delegate, "alice")
+	l.mustInvoke("owner", l.cc.Delegate, "bob")
+	expectVotes(l, map[string]string{"alice": "300", "bob": "700", "mallory": "0"})
+}
+
+func TestVotingPowerCheckpoints(t *testing.T) {
+	l := newTokenLedger(t, "owner", 1000)
+	l.mustInvoke("owner", l.cc.Transfer, "owner", "alice", "300")
+	l.mustInvoke("alice", l.cc.Delegate, "alice")
+	l.mustInvoke("owner", l.cc.Delegate, "bob")
+	delegated := l.now
+
+	l.now += 3600
+	l.mustInvoke("alice", l.cc.Transfer, "alice", "owner", "100")
+	expectVotes(l, map[string]string{"alice": "200", "bob": "800"})
+
+	l.now += 3600
+	for _, test := range []struct {
+		delegatee string
+		timestamp int64
+		expected  string
+	}{
+		{"bob", delegated - 1, "0"},
+		{"bob", delegated, "700"},
+		{"bob", delegated + 3599, "700"},
+		{"bob", delegated + 3600, "800"},
+		{"alice", delegated, "300"},
+		{"alice", delegated + 3600, "200"},
+	} {
+		if votes := l.mustInvoke("", l.cc.GetPastVotes, test.delegatee, strconv.FormatInt(test.timestamp, 10)); votes != test.expected {
+			t.Errorf("votes of %s at %d: expected %s, got %s", test.delegatee, test.timestamp, test.expected, votes)
+		}
+	}
+	l.mustFail("", "timestamp must be in the past", l.cc.GetPastVotes, "bob", strconv.FormatInt(l.now, 10))
+}
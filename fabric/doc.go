@@ -0,0 +1,9 @@
+// Package fabric selects the chaincode shim of the Fabric version the chaincode is built for.
+// The default build uses the Fabric 1.4 shim, the build with the fabric2 tag /
+// uses the Fabric 2.x shim and go.fabric2.mod:
+//
+//	go build -tags fabric2 -modfile go.fabric2.mod
+//
+// The controllers only depend on the Stub interface and the Response of this package, /
+// so the same business logic runs on both versions.
+package fabric
@@ -0,0 +1,80 @@
+//go:build fabric2
+// +build fabric2
+
+package fabric
+
+import (
+	"crypto/x509"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Stub is the chaincode stub of Fabric 2.x
+type Stub = shim.ChaincodeStubInterface
+
+// Chaincode is the chaincode interface of Fabric 2.x
+type Chaincode = shim.Chaincode
+
+// Response is the chaincode response of Fabric 2.x
+type Response = peer.Response
+
+// KV is the key and the value of the state query result of Fabric 2.x
+type KV = queryresult.KV
+
+// StateQueryIterator is the iterator of the state query result of Fabric 2.x
+type StateQueryIterator = shim.StateQueryIteratorInterface
+
+// SignedProposal is the signed proposal of the transaction of Fabric 2.x
+type SignedProposal = peer.SignedProposal
+
+// the messages of the proposal invoking the chaincode
+type (
+	proposal                 = peer.Proposal
+	chaincodeProposalPayload = peer.ChaincodeProposalPayload
+	chaincodeInvocationSpec  = peer.ChaincodeInvocationSpec
+	chaincodeSpec            = peer.ChaincodeSpec
+	chaincodeID              = peer.ChaincodeID
+)
+
+// OK is the status of the success response
+const OK = shim.OK
+
+// Success makes the success response with the payload
+func Success(payload []byte) Response {
+	return shim.Success(payload)
+}
+
+// Error makes the error response with the message
+func Error(msg string) Response {
+	return shim.Error(msg)
+}
+
+// GetX509Certificate gets the certificate of the transaction creator
+func GetX509Certificate(stub Stub) (*x509.Certificate, error) {
+	return cid.GetX509Certificate(stub)
+}
+
+// Start starts the chaincode connected to the peer
+func Start(cc Chaincode) error {
+	return shim.Start(cc)
+}
+
+// StartServer runs the chaincode as a service the peer connects to
+func StartServer(cc Chaincode, config *ServerConfig) error {
+	server := &shim.ChaincodeServer{
+		CCID:    config.CCID,
+		Address: config.Address,
+		CC:      cc,
+		TLSProps: shim.TLSProperties{
+			Disabled:      config.TLSDisabled,
+			Key:           config.TLSKey,
+			Cert:          config.TLSCert,
+			ClientCACerts: config.TLSClientCACert,
+		},
+	}
+
+	return server.Start()
+}
@@ -0,0 +1,53 @@
+package fabric
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// GetChaincodeName gets the name of the chaincode invoked by the proposal of the transaction, /
+// empty if the stub has no proposal like the mock stubs.
+// The chaincodes called by the chaincode get the name of the chaincode of the proposal.
+func GetChaincodeName(stub Stub) (string, error) {
+	signedProposal, err := stub.GetSignedProposal()
+	if err != nil || signedProposal == nil {
+		return "", err
+	}
+
+	prop := proposal{}
+	if err := proto.Unmarshal(signedProposal.ProposalBytes, &prop); err != nil {
+		return "", err
+	}
+
+	payload := chaincodeProposalPayload{}
+	if err := proto.Unmarshal(prop.Payload, &payload); err != nil {
+		return "", err
+	}
+
+	invocationSpec := chaincodeInvocationSpec{}
+	if err := proto.Unmarshal(payload.Input, &invocationSpec); err != nil {
+		return "", err
+	}
+
+	return invocationSpec.GetChaincodeSpec().GetChaincodeId().GetName(), nil
+}
+
+// NewSignedProposal makes the unsigned proposal invoking the chaincode for the mock stubs
+func NewSignedProposal(chaincodeName string) (*SignedProposal, error) {
+	invocationSpecBytes, err := proto.Marshal(&chaincodeInvocationSpec{
+		ChaincodeSpec: &chaincodeSpec{ChaincodeId: &chaincodeID{Name: chaincodeName}}})
+	if err != nil {
+		return nil, err
+	}
+
+	payloadBytes, err := proto.Marshal(&chaincodeProposalPayload{Input: invocationSpecBytes})
+	if err != nil {
+		return nil, err
+	}
+
+	proposalBytes, err := proto.Marshal(&proposal{Payload: payloadBytes})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedProposal{ProposalBytes: proposalBytes}, nil
+}
@@ -0,0 +1,11 @@
+package fabric
+
+// ServerConfig is the config of the chaincode running as a service
+type ServerConfig struct {
+	CCID            string
+	Address         string
+	TLSDisabled     bool
+	TLSKey          []byte
+	TLSCert         []byte
+	TLSClientCACert []byte
+}
@@ -0,0 +1,123 @@
+//go:build !fabric2
+// +build !fabric2
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hypherledgertest2/mockledger"
+)
+
+// subscriberBuffer is the number of the events kept for a slow subscriber before it is dropped
+const subscriberBuffer = 256
+
+// keepAliveInterval is the interval of the comments keeping the idle stream open
+var keepAliveInterval = 15 * time.Second
+
+// publish sends the events of the committed transaction to the subscribers, /
+// the subscriber whose buffer is full is dropped so that it cannot block the transactions
+func (g *Gateway) publish(events []mockledger.Event) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, event := range events {
+		for subscriber := range g.subscribers {
+			select {
+			case subscriber <- event:
+			default:
+				delete(g.subscribers, subscriber)
+				close(subscriber)
+			}
+		}
+	}
+}
+
+// subscribe adds the subscriber of the events, closed at once if the gateway is closed
+func (g *Gateway) subscribe() chan mockledger.Event {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	subscriber := make(chan mockledger.Event, subscriberBuffer)
+	if g.closed {
+		close(subscriber)
+		return subscriber
+	}
+	g.subscribers[subscriber] = true
+
+	return subscriber
+}
+
+func (g *Gateway) unsubscribe(subscriber chan mockledger.Event) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.subscribers[subscriber] {
+		delete(g.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// Close ends the event streams. http.Server.Shutdown does not cancel the contexts of the active requests, /
+// so register Close by http.Server.RegisterOnShutdown for the shutdown to wait only for the transactions.
+func (g *Gateway) Close() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.closed = true
+	for subscriber := range g.subscribers {
+		delete(g.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// events streams the events of the committed transactions as server-sent events, /
+// the id is the transaction id and the event is the name of the chaincode event.
+// The name query param filters the events by the name.
+func (g *Gateway) events(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	name := r.URL.Query().Get("name")
+
+	subscriber := g.subscribe()
+	defer g.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscriber:
+			if !ok {
+				return
+			}
+			if len(name) > 0 && event.Name != name {
+				continue
+			}
+
+			eventBytes, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.TxID, event.Name, eventBytes)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
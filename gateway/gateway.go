@@ -0,0 +1,171 @@
+//go:build !fabric2
+// +build !fabric2
+
+// Package gateway serves the REST API of the token chaincode hosted on the in-process mock ledger, /
+// for the development of the applications without a Fabric network.
+//
+// The creator of the transaction is the named identity of the X-Identity header, /
+// and the transaction time is the X-Tx-Time header (RFC3339 or unix seconds, default now).
+// The routes respond the json of mockledger.Result with the HTTP status of the chaincode response, /
+// except the chaincode errors(500) which are 400 Bad Request.
+// The events of the committed transactions are streamed by GET /events as server-sent events.
+// The gateway made by NewWithState saves the ledger to the state file after each committed transaction.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"hypherledgertest2/mockledger"
+)
+
+// Gateway is the http.Handler of the REST API of the mock ledger
+type Gateway struct {
+	ledger    *mockledger.Ledger
+	statePath string
+	routes    []route
+	// txMutex publishes the events and saves the ledger in the order of the transactions
+	txMutex     sync.Mutex
+	mutex       sync.Mutex
+	subscribers map[chan mockledger.Event]bool
+	closed      bool
+}
+
+// New is ...
+func New(ledger *mockledger.Ledger) *Gateway {
+	return NewWithState(ledger, "")
+}
+
+// NewWithState makes the gateway saving the ledger to the state file after each committed transaction, /
+// nothing is saved if the path is empty
+func NewWithState(ledger *mockledger.Ledger, statePath string) *Gateway {
+	g := &Gateway{ledger: ledger, statePath: statePath, subscribers: map[chan mockledger.Event]bool{}}
+	g.routes = g.newRoutes()
+
+	return g
+}
+
+// route is the handler of the method and the path, /
+// the {name} segments of the path are passed as the params
+type route struct {
+	method  string
+	path    []string
+	handler func(w http.ResponseWriter, r *http.Request, params map[string]string)
+}
+
+func newRoute(method, path string, handler func(w http.ResponseWriter, r *http.Request, params map[string]string)) route {
+	return route{method, strings.Split(strings.Trim(path, "/"), "/"), handler}
+}
+
+// match gets the params of the path, ok is false if the path does not match
+func (rt *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.path) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, segment := range rt.path {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if len(segments[i]) == 0 {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// ServeHTTP routes the request by the method and the path
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	methodNotAllowed := false
+	for i := range g.routes {
+		params, ok := g.routes[i].match(segments)
+		if !ok {
+			continue
+		}
+		if g.routes[i].method != r.Method {
+			methodNotAllowed = true
+			continue
+		}
+
+		g.routes[i].handler(w, r, params)
+		return
+	}
+
+	if methodNotAllowed {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	writeError(w, http.StatusNotFound, errors.New("route not found"))
+}
+
+// submit runs the transaction of the request and writes the result
+func (g *Gateway) submit(w http.ResponseWriter, r *http.Request, init bool, fcn string, args []string, transient map[string][]byte) {
+	txTime, err := mockledger.ParseTime(r.Header.Get("X-Tx-Time"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("X-Tx-Time "+err.Error()))
+		return
+	}
+
+	tx := mockledger.Transaction{
+		Identity:  r.Header.Get("X-Identity"),
+		Function:  fcn,
+		Args:      args,
+		Transient: transient,
+		Time:      txTime,
+	}
+
+	g.txMutex.Lock()
+	var result *mockledger.Result
+	if init {
+		result, err = g.ledger.Init(tx)
+	} else {
+		result, err = g.ledger.Invoke(tx)
+	}
+	if err == nil {
+		g.publish(result.Events)
+	}
+	// the failed transactions are rolled back by the ledger
+	if err == nil && result.OK() {
+		// the transaction is committed in memory, so the result is written even if the save fails
+		if saveErr := g.ledger.SaveFile(g.statePath); saveErr != nil {
+			log.Println("failed to save the ledger:", saveErr)
+		}
+	}
+	g.txMutex.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	status := int(result.Status)
+	if status == http.StatusInternalServerError {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, result)
+}
+
+// gatewayError is the json of the error of the gateway
+type gatewayError struct {
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, gatewayError{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,326 @@
+//go:build !fabric2
+// +build !fabric2
+
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hypherledgertest2/mockledger"
+	"hypherledgertest2/model"
+)
+
+// response is the json of the result or the error of the gateway
+type response struct {
+	TxID    string            `json:"txId"`
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Payload json.RawMessage   `json:"payload"`
+	Events  []json.RawMessage `json:"events"`
+}
+
+func request(t *testing.T, server *httptest.Server, method, path, identity, body string) (int, response) {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identity) > 0 {
+		req.Header.Set("X-Identity", identity)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	resBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resBody := response{}
+	if err := json.Unmarshal(resBytes, &resBody); err != nil {
+		t.Fatalf("%s %s: invalid json %s", method, path, resBytes)
+	}
+
+	return res.StatusCode, resBody
+}
+
+// mustRequest requests and checks the status is 200
+func mustRequest(t *testing.T, server *httptest.Server, method, path, identity, body string) response {
+	status, res := request(t, server, method, path, identity, body)
+	if status != http.StatusOK {
+		t.Fatalf("%s %s: expected 200, got %d %s", method, path, status, res.Message)
+	}
+
+	return res
+}
+
+// payload gets the payload of the response, the plain payloads are json strings
+func payload(t *testing.T, res response) string {
+	var value string
+	if err := json.Unmarshal(res.Payload, &value); err != nil {
+		return string(res.Payload)
+	}
+
+	return value
+}
+
+func newTokenServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(New(mockledger.New()))
+	mustRequest(t, server, "POST", "/init", "", `{"tokenName":"token","symbol":"TKN","owner":"owner","amount":1000}`)
+
+	return server
+}
+
+func TestGatewayTransfersAndAllowances(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+
+	alice := payload(t, mustRequest(t, server, "GET", "/me", "alice", ""))
+	if len(alice) != 40 {
+		t.Fatalf("expected the address of alice, got %s", alice)
+	}
+
+	res := mustRequest(t, server, "POST", "/transfers", "", `{"from":"owner","to":"bob","amount":"300"}`)
+	if len(res.TxID) == 0 || len(res.Events) != 1 {
+		t.Fatalf("expected the transaction id and the transfer event, got %+v", res)
+	}
+	if balance := payload(t, mustRequest(t, server, "GET", "/balances/bob", "", "")); balance != "300" {
+		t.Fatalf("expected the balance 300, got %s", balance)
+	}
+	if supply := payload(t, mustRequest(t, server, "GET", "/tokens/token/totalSupply", "", "")); supply != "1000" {
+		t.Fatalf("expected the total supply 1000, got %s", supply)
+	}
+
+	// alice spends the allowance of bob
+	mustRequest(t, server, "PUT", "/allowances/bob/"+alice, "", `{"amount":100}`)
+	mustRequest(t, server, "POST", "/allowances/bob/"+alice+"/increase", "", `{"amount":50}`)
+	if allowance := payload(t, mustRequest(t, server, "GET", "/allowances/bob/"+alice, "", "")); allowance != "150" {
+		t.Fatalf("expected the allowance 150, got %s", allowance)
+	}
+	mustRequest(t, server, "POST", "/transfers/from", "alice", `{"from":"bob","to":"carol","amount":120}`)
+	if balance := payload(t, mustRequest(t, server, "GET", "/balances/carol", "", "")); balance != "120" {
+		t.Fatalf("expected the balance 120, got %s", balance)
+	}
+
+	approvals := []model.ApprovalEvent{}
+	if err := json.Unmarshal(mustRequest(t, server, "GET", "/allowances/bob", "", "").Payload, &approvals); err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || approvals[0].Spender != alice || approvals[0].Amount != 30 {
+		t.Fatalf("expected the allowance 30 of alice, got %+v", approvals)
+	}
+
+	audit := model.AuditReport{}
+	if err := json.Unmarshal(mustRequest(t, server, "GET", "/tokens/token/audit", "", "").Payload, &audit); err != nil {
+		t.Fatal(err)
+	}
+	if !audit.Complete || !audit.Consistent {
+		t.Fatalf("expected the consistent ledger, got %+v", audit)
+	}
+}
+
+func TestGatewayErrors(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+
+	status, res := request(t, server, "POST", "/transfers", "", `{"from":"owner","to":"bob","amount":5000}`)
+	if status != http.StatusBadRequest || len(res.Message) == 0 || len(res.Events) != 0 {
+		t.Fatalf("expected 400 with the message, got %d %+v", status, res)
+	}
+	if balance := payload(t, mustRequest(t, server, "GET", "/balances/owner", "", "")); balance != "1000" {
+		t.Fatalf("the failed transfer must not change the balance, got %s", balance)
+	}
+
+	for _, test := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/transfers", `{"amount":`, http.StatusBadRequest},
+		{"POST", "/transfers", `{"from":"owner","to":"bob","amount":true}`, http.StatusBadRequest},
+		{"GET", "/unknown", "", http.StatusNotFound},
+		{"GET", "/balances/", "", http.StatusNotFound},
+		{"DELETE", "/balances/bob", "", http.StatusMethodNotAllowed},
+		{"POST", "/invoke/unknown", `{"args":[]}`, http.StatusNotFound},
+	} {
+		status, res := request(t, server, test.method, test.path, "", test.body)
+		if status != test.status || len(res.Message) == 0 {
+			t.Errorf("%s %s: expected %d with the message, got %d %+v", test.method, test.path, test.status, status, res)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/balances/owner", nil)
+	req.Header.Set("X-Tx-Time", "yesterday")
+	res2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res2.Body.Close()
+	if res2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid X-Tx-Time: expected 400, got %d", res2.StatusCode)
+	}
+}
+
+func TestGatewayInvokeWithTransient(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+
+	// alice deposits her public balance
+	alice := payload(t, mustRequest(t, server, "GET", "/me", "alice", ""))
+	mustRequest(t, server, "POST", "/transfers", "", `{"from":"owner","to":"`+alice+`","amount":100}`)
+
+	mustRequest(t, server, "POST", "/invoke/privateDeposit", "alice", `{"args":[],"transient":{"amount":"100","salt":"salt"}}`)
+	res := mustRequest(t, server, "POST", "/invoke/privateBalanceOf", "", `{"args":["`+alice+`"]}`)
+	if balance := payload(t, res); balance != "100" {
+		t.Fatalf("expected the private balance 100, got %s", balance)
+	}
+}
+
+func TestGatewayEventStream(t *testing.T) {
+	server := newTokenServer(t)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events?name=transferEvent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("expected the event stream, got %s", contentType)
+	}
+
+	reader := bufio.NewReader(res.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("expected the connected comment, got %q %v", line, err)
+	}
+	reader.ReadString('\n')
+
+	// the approval is filtered out
+	mustRequest(t, server, "PUT", "/allowances/owner/bob", "", `{"amount":10}`)
+	transfer := mustRequest(t, server, "POST", "/transfers", "", `{"from":"owner","to":"bob","amount":10}`)
+
+	lines := make(chan []string, 1)
+	go func() {
+		event := []string{}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\n" {
+				break
+			}
+			event = append(event, strings.TrimSuffix(line, "\n"))
+		}
+		lines <- event
+	}()
+
+	var event []string
+	select {
+	case event = <-lines:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event is streamed")
+	}
+
+	if len(event) != 3 || event[0] != "id: "+transfer.TxID || event[1] != "event: transferEvent" {
+		t.Fatalf("expected the transfer event of %s, got %q", transfer.TxID, event)
+	}
+	data := strings.TrimPrefix(event[2], "data: ")
+	if data != string(bytes.TrimSpace(transfer.Events[0])) {
+		t.Fatalf("expected the event of the response %s, got %s", transfer.Events[0], data)
+	}
+}
+
+func TestGatewayMintAndBurn(t *testing.T) {
+	server := httptest.NewServer(New(mockledger.New()))
+	defer server.Close()
+
+	// the owner of the token is the address of the identity minting
+	owner := payload(t, mustRequest(t, server, "GET", "/me", "owner", ""))
+	mustRequest(t, server, "POST", "/init", "", `{"tokenName":"token","symbol":"TKN","owner":"`+owner+`","amount":1000}`)
+
+	if status, res := request(t, server, "POST", "/tokens/token/mint", "bob", `{"to":"bob","amount":500}`); status != http.StatusBadRequest {
+		t.Fatalf("only the owner can mint: expected 400, got %d %+v", status, res)
+	}
+	mustRequest(t, server, "POST", "/tokens/token/mint", "owner", `{"to":"bob","amount":500}`)
+	mustRequest(t, server, "POST", "/tokens/token/burn", "owner", `{"amount":"200"}`)
+
+	if balance := payload(t, mustRequest(t, server, "GET", "/balances/"+owner, "", "")); balance != "800" {
+		t.Fatalf("expected the balance 800, got %s", balance)
+	}
+	if supply := payload(t, mustRequest(t, server, "GET", "/tokens/token/totalSupply", "", "")); supply != "1300" {
+		t.Fatalf("expected the total supply 1300, got %s", supply)
+	}
+}
+
+func TestGatewayInitWithInvalidAmount(t *testing.T) {
+	server := httptest.NewServer(New(mockledger.New()))
+	defer server.Close()
+
+	// the invalid amount fails the transaction instead of exiting the process
+	for _, amount := range []string{"-5", "1.5"} {
+		status, res := request(t, server, "POST", "/init", "", `{"tokenName":"token","symbol":"TKN","owner":"owner","amount":`+amount+`}`)
+		if status != http.StatusBadRequest || !strings.Contains(res.Message, "amount") {
+			t.Fatalf("init with the amount %s: expected 400 with the message, got %d %+v", amount, status, res)
+		}
+	}
+
+	mustRequest(t, server, "POST", "/init", "", `{"tokenName":"token","symbol":"TKN","owner":"owner","amount":1000}`)
+}
+
+func TestGatewaySavesEachCommittedTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erc20gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "ledger.json")
+
+	server := httptest.NewServer(NewWithState(mockledger.New(), state))
+	defer server.Close()
+	mustRequest(t, server, "POST", "/init", "", `{"tokenName":"token","symbol":"TKN","owner":"owner","amount":1000}`)
+	mustRequest(t, server, "POST", "/transfers", "", `{"from":"owner","to":"bob","amount":10}`)
+
+	// the state file has the transactions before the shutdown
+	ledger, err := mockledger.LoadFile(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ledger.Invoke(mockledger.Transaction{Function: "balanceOf", Args: []string{"bob"}})
+	if err != nil || string(result.Payload) != "10" {
+		t.Fatalf("expected the saved balance 10, got %+v %v", result, err)
+	}
+}
+
+func TestGatewayShutdownEndsTheEventStreams(t *testing.T) {
+	g := New(mockledger.New())
+	server := httptest.NewUnstartedServer(g)
+	server.Config.RegisterOnShutdown(g.Close)
+	server.Start()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if line, err := bufio.NewReader(res.Body).ReadString('\n'); err != nil || line != ": connected\n" {
+		t.Fatalf("expected the connected comment, got %q %v", line, err)
+	}
+
+	// the shutdown waits for the stream until the timeout unless the stream is ended
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("expected the graceful shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+//go:build !fabric2
+// +build !fabric2
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// amount is the amount of token in the request body, a json number or a decimal string
+type amount string
+
+func (a *amount) UnmarshalJSON(data []byte) error {
+	var decimal string
+	if err := json.Unmarshal(data, &decimal); err == nil {
+		*a = amount(decimal)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return errors.New("amount must be a number or a decimal string")
+	}
+	*a = amount(number)
+
+	return nil
+}
+
+// initRequest is the body of POST /init
+type initRequest struct {
+	TokenName string `json:"tokenName"`
+	Symbol    string `json:"symbol"`
+	Owner     string `json:"owner"`
+	Amount    amount `json:"amount"`
+}
+
+// transferRequest is the body of POST /transfers and POST /transfers/from(from is the owner)
+type transferRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount amount `json:"amount"`
+}
+
+// mintRequest is the body of POST /tokens/{token}/mint, the caller must be the owner of the token
+type mintRequest struct {
+	To     string `json:"to"`
+	Amount amount `json:"amount"`
+}
+
+// burnRequest is the body of POST /tokens/{token}/burn, the tokens of the caller are burned
+type burnRequest struct {
+	Amount amount `json:"amount"`
+}
+
+// allowanceRequest is the body of PUT /allowances/{owner}/{spender}, /
+// expiresAt is the unix timestamp of the expiry, 0 means no expiry
+type allowanceRequest struct {
+	Amount    amount `json:"amount"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// invokeRequest is the body of POST /invoke/{function}
+type invokeRequest struct {
+	Args      []string          `json:"args"`
+	Transient map[string]string `json:"transient"`
+}
+
+func (g *Gateway) newRoutes() []route {
+	return []route{
+		newRoute("POST", "/init", g.initToken),
+		newRoute("GET", "/me", g.query("callerAddress")),
+		newRoute("GET", "/tokens/{token}/totalSupply", g.query("totalSupply", "token")),
+		newRoute("GET", "/tokens/{token}/audit", g.audit),
+		newRoute("POST", "/tokens/{token}/mint", g.mint),
+		newRoute("POST", "/tokens/{token}/burn", g.burn),
+		newRoute("GET", "/balances/{address}", g.query("balanceOf", "address")),
+		newRoute("POST", "/transfers", g.transfer("transfer")),
+		newRoute("POST", "/transfers/from", g.transfer("transferFrom")),
+		newRoute("GET", "/allowances/{owner}", g.query("approvalList", "owner")),
+		newRoute("GET", "/allowances/{owner}/{spender}", g.query("allowance", "owner", "spender")),
+		newRoute("PUT", "/allowances/{owner}/{spender}", g.approve),
+		newRoute("POST", "/allowances/{owner}/{spender}/increase", g.changeAllowance("increaseAllowance")),
+		newRoute("POST", "/allowances/{owner}/{spender}/decrease", g.changeAllowance("decreaseAllowance")),
+		newRoute("POST", "/invoke/{function}", g.invoke),
+		newRoute("GET", "/events", g.events),
+	}
+}
+
+// decode decodes the json body of the request, writes 400 Bad Request if it fails
+func decode(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body: "+err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// query invokes the function with the params of the path in order
+func (g *Gateway) query(fcn string, paramNames ...string) func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		args := []string{}
+		for _, name := range paramNames {
+			args = append(args, params[name])
+		}
+
+		g.submit(w, r, false, fcn, args, nil)
+	}
+}
+
+func (g *Gateway) initToken(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := initRequest{}
+	if decode(w, r, &body) {
+		g.submit(w, r, true, "init", []string{body.TokenName, body.Symbol, body.Owner, string(body.Amount)}, nil)
+	}
+}
+
+// audit invokes the audit with the pageSize(default 100) and the bookmark of the query
+func (g *Gateway) audit(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	pageSize := r.URL.Query().Get("pageSize")
+	if len(pageSize) == 0 {
+		pageSize = "100"
+	}
+
+	g.submit(w, r, false, "audit", []string{params["token"], pageSize, r.URL.Query().Get("bookmark")}, nil)
+}
+
+func (g *Gateway) mint(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := mintRequest{}
+	if decode(w, r, &body) {
+		g.submit(w, r, false, "mint", []string{params["token"], body.To, string(body.Amount)}, nil)
+	}
+}
+
+func (g *Gateway) burn(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := burnRequest{}
+	if decode(w, r, &body) {
+		g.submit(w, r, false, "burn", []string{params["token"], string(body.Amount)}, nil)
+	}
+}
+
+// transfer invokes transfer, or transferFrom spending the allowance of the caller
+func (g *Gateway) transfer(fcn string) func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		body := transferRequest{}
+		if decode(w, r, &body) {
+			g.submit(w, r, false, fcn, []string{body.From, body.To, string(body.Amount)}, nil)
+		}
+	}
+}
+
+// approve invokes approve, or approveWithExpiry if the expiry is set
+func (g *Gateway) approve(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := allowanceRequest{}
+	if !decode(w, r, &body) {
+		return
+	}
+
+	args := []string{params["owner"], params["spender"], string(body.Amount)}
+	if body.ExpiresAt == 0 {
+		g.submit(w, r, false, "approve", args, nil)
+		return
+	}
+	g.submit(w, r, false, "approveWithExpiry", append(args, strconv.FormatInt(body.ExpiresAt, 10)), nil)
+}
+
+// changeAllowance invokes increaseAllowance or decreaseAllowance
+func (g *Gateway) changeAllowance(fcn string) func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		body := allowanceRequest{}
+		if decode(w, r, &body) {
+			g.submit(w, r, false, fcn, []string{params["owner"], params["spender"], string(body.Amount)}, nil)
+		}
+	}
+}
+
+// invoke invokes any function of the chaincode with the args and the transient data of the body
+func (g *Gateway) invoke(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	body := invokeRequest{}
+	if !decode(w, r, &body) {
+		return
+	}
+
+	transient := map[string][]byte{}
+	for key, value := range body.Transient {
+		transient[key] = []byte(value)
+	}
+
+	g.submit(w, r, false, params["function"], body.Args, transient)
+}
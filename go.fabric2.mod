@@ -0,0 +1,14 @@
+// go.fabric2.mod is the module of the Fabric 2.x build of the chaincode:
+//
+//	go mod tidy -modfile go.fabric2.mod
+//	go build -tags fabric2 -modfile go.fabric2.mod
+module hypherledgertest2
+
+go 1.14
+
+require (
+	github.com/golang/protobuf v1.3.2
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200424173110-d7076418f212
+	github.com/hyperledger/fabric-contract-api-go v1.1.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20200424173316-dd554ba3746e
+)
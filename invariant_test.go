@@ -0,0 +1,323 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hypherledgertest2/controller"
+	"hypherledgertest2/mockledger"
+	"hypherledgertest2/model"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// invariantSteps is the number of the random operations of a run
+const invariantSteps = 500
+
+// invariantSeed is the seed of the random operations by default, so that the runs are reproducible
+const invariantSeed = 20300101
+
+// getInvariantSeed gets the seed of the random operations, /
+// set INVARIANT_SEED to a number to replay a failed run or to random for a new seed
+func getInvariantSeed(t *testing.T) int64 {
+	seed := int64(invariantSeed)
+	switch seedEnv := os.Getenv("INVARIANT_SEED"); seedEnv {
+	case "":
+	case "random":
+		seed = time.Now().UnixNano()
+	default:
+		var err error
+		seed, err = strconv.ParseInt(seedEnv, 10, 64)
+		if err != nil {
+			t.Fatal("INVARIANT_SEED must be a number or random")
+		}
+	}
+
+	t.Logf("INVARIANT_SEED=%d", seed)
+	return seed
+}
+
+// ledgerState is the copy of the balances, the allowances and the total supply
+type ledgerState struct {
+	raw         map[string][]byte
+	balances    map[string]int
+	allowances  map[string]int
+	totalSupply int
+}
+
+// readLedgerState reads every balance and allowance saved in the mock stub
+func readLedgerState(t *testing.T, stub *shim.MockStub) *ledgerState {
+	state := &ledgerState{raw: map[string][]byte{}, balances: map[string]int{}, allowances: map[string]int{}}
+
+	for key, value := range stub.State {
+		state.raw[key] = value
+
+		switch {
+		case key == "token":
+			erc20 := model.ERC20Metadata{}
+			if err := json.Unmarshal(value, &erc20); err != nil {
+				t.Fatal("malformed metadata", err)
+			}
+			state.totalSupply = int(erc20.TotalSupply)
+		case strings.HasPrefix(key, "\x00approval\x00"):
+			allowance := model.Allowance{}
+			if err := json.Unmarshal(value, &allowance); err != nil {
+				t.Fatalf("malformed allowance %q: %s", key, value)
+			}
+			state.allowances[key] = allowance.Amount
+		case !strings.HasPrefix(key, "\x00"):
+			balance, err := strconv.Atoi(string(value))
+			if err != nil {
+				t.Fatalf("malformed balance of %s: %s", key, value)
+			}
+			state.balances[key] = balance
+		}
+	}
+
+	return state
+}
+
+// checkInvariants checks the invariants that hold after every operation
+func checkInvariants(t *testing.T, state *ledgerState) {
+	sum := 0
+	for address, balance := range state.balances {
+		if balance < 0 {
+			t.Fatalf("negative balance of %s: %d", address, balance)
+		}
+		sum += balance
+	}
+	if sum != state.totalSupply {
+		t.Fatalf("sum of balances %d != total supply %d", sum, state.totalSupply)
+	}
+
+	for key, amount := range state.allowances {
+		if amount < 0 {
+			t.Fatalf("negative allowance %q: %d", key, amount)
+		}
+	}
+}
+
+// invariantRun is the random sequence of the operations against the token
+type invariantRun struct {
+	t         *testing.T
+	f         *erc20Fixture
+	rand      *rand.Rand
+	owner     string
+	creators  map[string]string
+	addresses []string
+}
+
+func newInvariantRun(t *testing.T, seed int64) *invariantRun {
+	f, owner := newOwnedFixture(t, 1000000)
+	ownerAddress := f.address(owner)
+	run := &invariantRun{
+		t:         t,
+		f:         f,
+		rand:      rand.New(rand.NewSource(seed)),
+		owner:     ownerAddress,
+		creators:  map[string]string{ownerAddress: owner},
+		addresses: []string{ownerAddress},
+	}
+
+	for i := 0; i < 4; i++ {
+		creator := "holder" + strconv.Itoa(i)
+		address := run.f.address(creator)
+		run.creators[address] = creator
+		run.addresses = append(run.addresses, address)
+	}
+
+	return run
+}
+
+func (r *invariantRun) pick() string {
+	return r.addresses[r.rand.Intn(len(r.addresses))]
+}
+
+// amount picks a random amount around the limit, sometimes over it or malformed
+func (r *invariantRun) amount(limit int) string {
+	switch r.rand.Intn(20) {
+	case 0:
+		return "-1"
+	case 1:
+		return "0"
+	case 2:
+		return "abc"
+	}
+
+	return strconv.Itoa(r.rand.Intn(limit*6/5 + 2))
+}
+
+func (r *invariantRun) balance(state *ledgerState, address string) int {
+	return state.balances[address]
+}
+
+func (r *invariantRun) allowance(state *ledgerState, owner, spender string) (int, string) {
+	approvalKey, _ := r.f.ledger.MockStub().CreateCompositeKey("approval", []string{owner, spender})
+	return state.allowances[approvalKey], approvalKey
+}
+
+// step runs a random operation and checks the invariants and the events against the state deltas
+func (r *invariantRun) step() {
+	before := readLedgerState(r.t, r.f.ledger.MockStub())
+
+	var fcn string
+	var params []string
+	var creator string
+	switch r.rand.Intn(5) {
+	case 0:
+		sender := r.pick()
+		fcn, params = "transfer", []string{sender, r.pick(), r.amount(r.balance(before, sender))}
+	case 1:
+		amount := r.amount(r.balance(before, r.owner))
+		if r.rand.Intn(10) == 0 {
+			amount = strconv.Itoa(controller.InfiniteAllowance)
+		}
+		fcn, params = "approve", []string{r.pick(), r.pick(), amount}
+	case 2:
+		owner, spender := r.pick(), r.pick()
+		allowance, _ := r.allowance(before, owner, spender)
+		if allowance > r.balance(before, owner) {
+			allowance = r.balance(before, owner)
+		}
+		fcn, params, creator = "transferFrom", []string{owner, r.pick(), r.amount(allowance)}, r.creators[spender]
+	case 3:
+		minter := r.owner
+		if r.rand.Intn(5) == 0 {
+			minter = r.pick()
+		}
+		fcn, params, creator = "mint", []string{"token", r.pick(), r.amount(10000)}, r.creators[minter]
+	case 4:
+		caller := r.pick()
+		fcn, params, creator = "burn", []string{"token", r.amount(r.balance(before, caller))}, r.creators[caller]
+	}
+
+	res := r.f.invoke(creator, fcn, params...)
+	events := res.Events
+	after := readLedgerState(r.t, r.f.ledger.MockStub())
+	checkInvariants(r.t, after)
+
+	// failed operations change nothing
+	if res.Status != shim.OK {
+		if len(events) != 0 {
+			r.t.Fatalf("failed %s%v emitted %d events", fcn, params, len(events))
+		}
+		if len(before.raw) != len(after.raw) {
+			r.t.Fatalf("failed %s%v changed the state", fcn, params)
+		}
+		for key, value := range before.raw {
+			if !bytes.Equal(value, after.raw[key]) {
+				r.t.Fatalf("failed %s%v changed %q", fcn, params, key)
+			}
+		}
+		return
+	}
+
+	if len(events) != 1 {
+		r.t.Fatalf("%s%v emitted %d events", fcn, params, len(events))
+	}
+
+	if fcn == "approve" {
+		r.checkApprovalEvent(events[0], before, after)
+		return
+	}
+	r.checkTransferEvent(events[0], before, after)
+
+	// transferFrom spends the caller's allowance
+	if fcn == "transferFrom" {
+		spender := string(r.f.mustInvoke(creator, "callerAddress"))
+		allowanceBefore, _ := r.allowance(before, params[0], spender)
+		allowanceAfter, _ := r.allowance(after, params[0], spender)
+		amount, _ := strconv.Atoi(params[2])
+		expected := allowanceBefore - amount
+		if allowanceBefore == controller.InfiniteAllowance {
+			expected = allowanceBefore
+		}
+		if allowanceAfter != expected {
+			r.t.Fatalf("transferFrom%v: allowance %d -> %d", params, allowanceBefore, allowanceAfter)
+		}
+	}
+}
+
+// checkTransferEvent checks the balances and the total supply moved as the transfer event
+func (r *invariantRun) checkTransferEvent(event mockledger.Event, before, after *ledgerState) {
+	if event.Name != "transferEvent" {
+		r.t.Fatalf("expected transferEvent, got %s", event.Name)
+	}
+
+	transferedEvent := model.TransferedEvent{}
+	if err := json.Unmarshal(event.Payload, &transferedEvent); err != nil {
+		r.t.Fatal(err)
+	}
+	amount, err := strconv.Atoi(transferedEvent.TransferedMoney)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	// the empty sender is the mint, the empty recipient is the burn
+	expected := map[string]int{}
+	supplyDelta := 0
+	if transferedEvent.Sender == "" {
+		supplyDelta += amount
+	} else {
+		expected[transferedEvent.Sender] -= amount
+	}
+	if transferedEvent.Recipient == "" {
+		supplyDelta -= amount
+	} else {
+		expected[transferedEvent.Recipient] += amount
+	}
+
+	for _, address := range r.addresses {
+		if delta := after.balances[address] - before.balances[address]; delta != expected[address] {
+			r.t.Fatalf("%+v: balance of %s changed by %d, expected %d", transferedEvent, address, delta, expected[address])
+		}
+	}
+	if delta := after.totalSupply - before.totalSupply; delta != supplyDelta {
+		r.t.Fatalf("%+v: total supply changed by %d, expected %d", transferedEvent, delta, supplyDelta)
+	}
+}
+
+// checkApprovalEvent checks the allowance is set as the approval event and the balances are not moved
+func (r *invariantRun) checkApprovalEvent(event mockledger.Event, before, after *ledgerState) {
+	if event.Name != "approvalEvent" {
+		r.t.Fatalf("expected approvalEvent, got %s", event.Name)
+	}
+
+	approvalEvent := model.ApprovalEvent{}
+	if err := json.Unmarshal(event.Payload, &approvalEvent); err != nil {
+		r.t.Fatal(err)
+	}
+
+	allowance, _ := r.allowance(after, approvalEvent.Owner, approvalEvent.Spender)
+	if allowance != approvalEvent.Amount {
+		r.t.Fatalf("%+v: allowance is %d", approvalEvent, allowance)
+	}
+
+	for _, address := range r.addresses {
+		if before.balances[address] != after.balances[address] {
+			r.t.Fatalf("%+v: balance of %s changed", approvalEvent, address)
+		}
+	}
+}
+
+func TestLedgerInvariants(t *testing.T) {
+	run := newInvariantRun(t, getInvariantSeed(t))
+	checkInvariants(t, readLedgerState(t, run.f.ledger.MockStub()))
+
+	for i := 0; i < invariantSteps; i++ {
+		run.step()
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"hypherledgertest2/chaincode"
+	"hypherledgertest2/fabric"
+)
+
+// newChaincode makes the chaincode started on Fabric 1.4
+func newChaincode() fabric.Chaincode {
+	return chaincode.NewChaincode()
+}
@@ -0,0 +1,24 @@
+//go:build fabric2
+// +build fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"hypherledgertest2/fabric"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// newChaincode makes the contract API chaincode started on Fabric 2.x
+func newChaincode() fabric.Chaincode {
+	contractChaincode, err := contractapi.NewChaincode(NewContract())
+	if err != nil {
+		panic(err)
+	}
+
+	return contractChaincode
+}
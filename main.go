@@ -4,10 +4,36 @@
 
 package main
 
-import "github.com/hyperledger/fabric/core/chaincode/shim"
+import (
+	"hypherledgertest2/fabric"
+	"log"
+	"os"
+)
 
 func main() {
-	err := shim.Start(new(ERC20Chaincode))
+	// the chaincode runs as a service when the server address is set, /
+	// otherwise it connects to the peer that launched it
+	if os.Getenv("CHAINCODE_SERVER_ADDRESS") == "" {
+		err := fabric.Start(newChaincode())
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	config, err := loadServerConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	healthAddress := os.Getenv("CHAINCODE_HEALTH_ADDRESS")
+	if healthAddress != "" {
+		go func() {
+			log.Fatalln(serveHealth(healthAddress, config.Address))
+		}()
+	}
+
+	err = fabric.StartServer(newChaincode(), config)
 	if err != nil {
 		panic(err)
 	}
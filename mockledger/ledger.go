@@ -0,0 +1,333 @@
+//go:build !fabric2
+// +build !fabric2
+
+// Package mockledger hosts the token chaincode on the in-process mock stub of Fabric 1.4, /
+// so that the local tools can drive it without a Fabric network.
+// The writes and the events of a failed transaction are discarded as the peer would not commit them.
+package mockledger
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"hypherledgertest2/chaincode"
+	"hypherledgertest2/fabric"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Ledger is the token chaincode deployed to the mock stub, invoked by the named identities.
+// It is safe for concurrent use, the transactions run one by one.
+type Ledger struct {
+	mutex      sync.Mutex
+	stub       *shim.MockStub
+	cc         *chaincode.ERC20Chaincode
+	txNum      int
+	identities map[string][]byte
+}
+
+// New is ...
+func New() *Ledger {
+	cc := chaincode.NewChaincode()
+
+	return &Ledger{stub: shim.NewMockStub("erc20", cc), cc: cc, identities: map[string][]byte{}}
+}
+
+// Transaction is the proposal of the function to the ledger
+type Transaction struct {
+	// Identity is the name of the creator, created on the first use, no creator if it is empty
+	Identity  string
+	Function  string
+	Args      []string
+	Transient map[string][]byte
+	// Time is the transaction timestamp, now if it is zero
+	Time time.Time
+}
+
+// Event is the chaincode event emitted by the transaction
+type Event struct {
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+// Result is the response and the events of the transaction
+type Result struct {
+	TxID    string
+	Status  int32
+	Message string
+	Payload []byte
+	Events  []Event
+}
+
+// MarshalJSON writes the payload as json if it is a json object or array, as string otherwise
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		TxID    string          `json:"txId"`
+		Name    string          `json:"name"`
+		Payload json.RawMessage `json:"payload"`
+	}{e.TxID, e.Name, jsonPayload(e.Payload)})
+}
+
+// MarshalJSON writes the payload as json if it is a json object or array, as string otherwise
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		TxID    string          `json:"txId"`
+		Status  int32           `json:"status"`
+		Message string          `json:"message,omitempty"`
+		Payload json.RawMessage `json:"payload"`
+		Events  []Event         `json:"events"`
+	}{r.TxID, r.Status, r.Message, jsonPayload(r.Payload), r.Events})
+}
+
+// jsonPayload keeps the json objects and arrays, and quotes the other payloads, /
+// so that the numbers and the addresses are always strings
+func jsonPayload(payload []byte) json.RawMessage {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return trimmed
+	}
+
+	quoted, _ := json.Marshal(string(payload))
+	return quoted
+}
+
+// OK is true if the transaction succeeded and its writes are committed
+func (r *Result) OK() bool {
+	return r.Status < shim.ERRORTHRESHOLD
+}
+
+// Init initializes the token, or keeps its state on the upgrade /
+// args : tokenName, symbol, owner(address), amount
+func (l *Ledger) Init(tx Transaction) (*Result, error) {
+	return l.execute(tx, true)
+}
+
+// Invoke runs the function of the chaincode as a transaction
+func (l *Ledger) Invoke(tx Transaction) (*Result, error) {
+	return l.execute(tx, false)
+}
+
+// Address gets the address of the identity derived by the chaincode
+func (l *Ledger) Address(identity string) (string, error) {
+	result, err := l.Invoke(Transaction{Identity: identity, Function: "callerAddress"})
+	if err != nil {
+		return "", err
+	}
+	if !result.OK() {
+		return "", fmt.Errorf("failed to get the address of %s: %s", identity, result.Message)
+	}
+
+	return string(result.Payload), nil
+}
+
+// Identities gets the names of the identities in order
+func (l *Ledger) Identities() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	names := make([]string, 0, len(l.identities))
+	for name := range l.identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// DeployChaincode deploys the chaincode to the mock ledger as the peer called back by the token.
+// The peer chaincodes are not saved with the ledger.
+func (l *Ledger) DeployChaincode(name string, cc shim.Chaincode) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stub.MockPeerChaincode(name, shim.NewMockStub(name, cc))
+}
+
+// MockStub gets the mock stub of the token, for the tests reading or corrupting the world state. /
+// It must not be used during a transaction.
+func (l *Ledger) MockStub() *shim.MockStub {
+	return l.stub
+}
+
+// ParseTime parses the transaction time of RFC3339 or unix seconds, zero(now) if it is empty
+func ParseTime(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	txTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("time must be RFC3339 or unix seconds")
+	}
+
+	return txTime, nil
+}
+
+func (l *Ledger) execute(tx Transaction, init bool) (*Result, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	creator, err := l.creator(tx.Identity)
+	if err != nil {
+		return nil, err
+	}
+
+	txTime := tx.Time
+	if txTime.IsZero() {
+		txTime = time.Now()
+	}
+
+	l.txNum++
+	txID := "tx" + strconv.Itoa(l.txNum)
+	stub := &ledgerStub{l.stub, creator, txTime, tx.Transient, tx.Function, tx.Args}
+
+	committed := l.snapshotState()
+	l.stub.MockTransactionStart(txID)
+	response := l.run(stub, init)
+	l.stub.MockTransactionEnd(txID)
+	events := l.drainEvents(txID)
+
+	result := &Result{TxID: txID, Status: response.Status, Message: response.Message, Payload: response.Payload, Events: events}
+	if !result.OK() {
+		l.restoreState(committed)
+		result.Events = []Event{}
+	}
+
+	return result, nil
+}
+
+// run runs the chaincode, the panic of the chaincode fails the transaction
+func (l *Ledger) run(stub *ledgerStub, init bool) (response sc.Response) {
+	defer func() {
+		if r := recover(); r != nil {
+			response = shim.Error(fmt.Sprint("chaincode panicked: ", r))
+		}
+	}()
+
+	if init {
+		return l.cc.Init(stub)
+	}
+
+	return l.cc.Invoke(stub)
+}
+
+// creator gets the serialized identity of the name, nil if the name is empty
+func (l *Ledger) creator(name string) ([]byte, error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+
+	creator, ok := l.identities[name]
+	if !ok {
+		var err error
+		creator, err = NewIdentity(name)
+		if err != nil {
+			return nil, err
+		}
+		l.identities[name] = creator
+	}
+
+	return creator, nil
+}
+
+// drainEvents gets the events emitted by the transaction
+func (l *Ledger) drainEvents(txID string) []Event {
+	events := []Event{}
+	for {
+		select {
+		case event := <-l.stub.ChaincodeEventsChannel:
+			events = append(events, Event{TxID: txID, Name: event.EventName, Payload: event.Payload})
+		default:
+			return events
+		}
+	}
+}
+
+// mockState is the copy of the world state and the private data of the mock stub
+type mockState struct {
+	state   map[string][]byte
+	keys    *list.List
+	private map[string]map[string][]byte
+}
+
+func (l *Ledger) snapshotState() *mockState {
+	snapshot := &mockState{state: map[string][]byte{}, keys: list.New(), private: map[string]map[string][]byte{}}
+	for key, value := range l.stub.State {
+		snapshot.state[key] = value
+	}
+	for element := l.stub.Keys.Front(); element != nil; element = element.Next() {
+		snapshot.keys.PushBack(element.Value)
+	}
+	for collection, values := range l.stub.PvtState {
+		snapshot.private[collection] = map[string][]byte{}
+		for key, value := range values {
+			snapshot.private[collection][key] = value
+		}
+	}
+
+	return snapshot
+}
+
+func (l *Ledger) restoreState(snapshot *mockState) {
+	l.stub.State = snapshot.state
+	l.stub.Keys = snapshot.keys
+	l.stub.PvtState = snapshot.private
+}
+
+// ledgerStub is the mock stub of the transaction proposed by the creator at the given time
+type ledgerStub struct {
+	*shim.MockStub
+	creator   []byte
+	txTime    time.Time
+	transient map[string][]byte
+	fcn       string
+	params    []string
+}
+
+func (s *ledgerStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *ledgerStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return &timestamp.Timestamp{Seconds: s.txTime.Unix(), Nanos: int32(s.txTime.Nanosecond())}, nil
+}
+
+func (s *ledgerStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *ledgerStub) GetFunctionAndParameters() (string, []string) {
+	return s.fcn, s.params
+}
+
+func (s *ledgerStub) GetSignedProposal() (*sc.SignedProposal, error) {
+	return fabric.NewSignedProposal(s.Name)
+}
+
+// InvokeChaincode invokes the peer chaincode deployed by DeployChaincode
+func (s *ledgerStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) sc.Response {
+	// the mock stub names the peers of another channel as chaincode/channel
+	peerName := chaincodeName
+	if len(channel) > 0 {
+		peerName += "/" + channel
+	}
+	if _, ok := s.Invokables[peerName]; !ok {
+		return shim.Error("chaincode " + chaincodeName + " is not deployed to the mock ledger")
+	}
+
+	return s.MockStub.InvokeChaincode(chaincodeName, args, channel)
+}
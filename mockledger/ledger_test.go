@@ -0,0 +1,172 @@
+//go:build !fabric2
+// +build !fabric2
+
+package mockledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+func mustInvoke(t *testing.T, l *Ledger, tx Transaction) *Result {
+	result, err := l.Invoke(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK() {
+		t.Fatalf("%s%v failed: %s", tx.Function, tx.Args, result.Message)
+	}
+
+	return result
+}
+
+func newTokenLedger(t *testing.T) *Ledger {
+	l := New()
+	result, err := l.Init(Transaction{Function: "init", Args: []string{"token", "TKN", "owner", "1000"}})
+	if err != nil || !result.OK() {
+		t.Fatal("Init failed", err, result)
+	}
+
+	return l
+}
+
+func TestLedgerIdentities(t *testing.T) {
+	l := newTokenLedger(t)
+
+	alice, err := l.Address("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := l.Address("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice == bob || len(alice) != 40 {
+		t.Fatalf("expected two addresses, got %s and %s", alice, bob)
+	}
+	if again, _ := l.Address("alice"); again != alice {
+		t.Fatalf("the identity must be kept, got %s and %s", alice, again)
+	}
+	if names := l.Identities(); len(names) != 2 || names[0] != "alice" || names[1] != "bob" {
+		t.Fatalf("expected alice and bob, got %v", names)
+	}
+
+	// no creator without the identity
+	if _, err := l.Address(""); err == nil {
+		t.Fatal("address without the identity must fail")
+	}
+}
+
+func TestLedgerRollsBackFailedTransactions(t *testing.T) {
+	l := newTokenLedger(t)
+
+	result := mustInvoke(t, l, Transaction{Function: "transfer", Args: []string{"owner", "alice", "100"}})
+	if len(result.Events) != 1 || result.Events[0].Name != "transferEvent" {
+		t.Fatalf("expected the transfer event, got %+v", result.Events)
+	}
+
+	// transferAndCall writes the transfer before the callback fails
+	result, err := l.Invoke(Transaction{Function: "transferAndCall", Args: []string{"owner", "receiver", "100", ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK() || len(result.Events) != 0 {
+		t.Fatalf("the callback to a missing chaincode must fail without events, got %+v", result)
+	}
+
+	for address, expected := range map[string]string{"owner": "900", "alice": "100"} {
+		balance := mustInvoke(t, l, Transaction{Function: "balanceOf", Args: []string{address}})
+		if string(balance.Payload) != expected {
+			t.Fatalf("balance of %s: expected %s, got %s", address, expected, balance.Payload)
+		}
+	}
+	if result, _ := l.Invoke(Transaction{Function: "balanceOf", Args: []string{"receiver"}}); result.OK() {
+		t.Fatal("the balance of the failed transfer must be rolled back")
+	}
+}
+
+// receiverChaincode accepts the callbacks of the token
+type receiverChaincode struct{}
+
+func (c *receiverChaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (c *receiverChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func TestLedgerDeployChaincode(t *testing.T) {
+	l := newTokenLedger(t)
+	l.DeployChaincode("receiver", &receiverChaincode{})
+
+	mustInvoke(t, l, Transaction{Function: "transferAndCall", Args: []string{"owner", "receiver", "100", ""}})
+	if balance := mustInvoke(t, l, Transaction{Function: "balanceOf", Args: []string{"receiver"}}); string(balance.Payload) != "100" {
+		t.Fatalf("expected the balance 100 of the receiver chaincode, got %s", balance.Payload)
+	}
+}
+
+func TestLedgerSaveAndLoad(t *testing.T) {
+	l := newTokenLedger(t)
+	alice, _ := l.Address("alice")
+	mustInvoke(t, l, Transaction{Function: "transfer", Args: []string{"owner", alice, "250"}})
+	mustInvoke(t, l, Transaction{Function: "approve", Args: []string{"owner", alice, "50"}})
+	mustInvoke(t, l, Transaction{
+		Identity:  "alice",
+		Function:  "privateDeposit",
+		Transient: map[string][]byte{"amount": []byte("100"), "salt": []byte("salt")}})
+
+	saved := &bytes.Buffer{}
+	if err := l.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, _ := loaded.Address("alice"); address != alice {
+		t.Fatalf("the identity must be loaded, expected %s, got %s", alice, address)
+	}
+	if balance := mustInvoke(t, loaded, Transaction{Function: "balanceOf", Args: []string{alice}}); string(balance.Payload) != "150" {
+		t.Fatalf("expected the balance 150, got %s", balance.Payload)
+	}
+	if private := mustInvoke(t, loaded, Transaction{Function: "privateBalanceOf", Args: []string{alice}}); string(private.Payload) != "100" {
+		t.Fatalf("expected the private balance 100, got %s", private.Payload)
+	}
+
+	// the range queries need the sorted keys
+	approvals := mustInvoke(t, loaded, Transaction{Function: "approvalList", Args: []string{"owner"}})
+	if !bytes.Contains(approvals.Payload, []byte(alice)) {
+		t.Fatalf("expected the approval to %s, got %s", alice, approvals.Payload)
+	}
+
+	// the transaction ids continue
+	result := mustInvoke(t, loaded, Transaction{Function: "totalSupply", Args: []string{"token"}})
+	if result.TxID == "tx1" {
+		t.Fatal("the transaction ids must not restart")
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	result := Result{
+		TxID:    "tx1",
+		Status:  200,
+		Payload: []byte("1000"),
+		Events:  []Event{{TxID: "tx1", Name: "transferEvent", Payload: []byte(`{"sender":"owner"}`)}},
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"txId":"tx1","status":200,"payload":"1000","events":[{"txId":"tx1","name":"transferEvent","payload":{"sender":"owner"}}]}`
+	if string(resultBytes) != expected {
+		t.Fatalf("expected %s, got %s", expected, resultBytes)
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !fabric2
+// +build !fabric2
+
+package mockledger
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// savedLedger is the json of the saved ledger, the values are base64 encoded
+type savedLedger struct {
+	TxNum      int                          `json:"txNum"`
+	State      map[string][]byte            `json:"state"`
+	Private    map[string]map[string][]byte `json:"private"`
+	Identities map[string][]byte            `json:"identities"`
+}
+
+// Save writes the world state, the private data, the identities and the number of the transactions.
+// The events are not saved.
+func (l *Ledger) Save(w io.Writer) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(savedLedger{
+		TxNum:      l.txNum,
+		State:      l.stub.State,
+		Private:    l.stub.PvtState,
+		Identities: l.identities,
+	})
+}
+
+// Load reads the ledger written by Save
+func Load(r io.Reader) (*Ledger, error) {
+	saved := savedLedger{}
+	err := json.NewDecoder(r).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+
+	l := New()
+	l.txNum = saved.TxNum
+	if saved.Identities != nil {
+		l.identities = saved.Identities
+	}
+	if saved.Private != nil {
+		l.stub.PvtState = saved.Private
+	}
+
+	// the mock stub keeps the keys sorted for the range queries
+	keys := make([]string, 0, len(saved.State))
+	for key := range saved.State {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		l.stub.State[key] = saved.State[key]
+		l.stub.Keys.PushBack(key)
+	}
+
+	return l, nil
+}
+
+// LoadFile loads the ledger from the file, a new ledger if the path is empty or the file does not exist
+func LoadFile(path string) (*Ledger, error) {
+	if len(path) == 0 {
+		return New(), nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+// SaveFile replaces the file with the ledger, nothing if the path is empty
+func (l *Ledger) SaveFile(path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = l.Save(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
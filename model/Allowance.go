@@ -0,0 +1,8 @@
+package model
+
+// Allowance is the record of the allowance saved under the approval composite key.
+// ExpiresAt is the unix timestamp from which the allowance is treated as zero, 0 means no expiry.
+type Allowance struct {
+	Amount    int   `json:"amount"`
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
@@ -2,12 +2,13 @@ package model
 
 // ApprovalEvent is the log of the ApprovalEvent
 type ApprovalEvent struct {
-	Owner   string `json:"owner"`
-	Spender string `json:"spender"`
-	Amount  int    `json:"amount"`
+	Owner     string `json:"owner"`
+	Spender   string `json:"spender"`
+	Amount    int    `json:"amount"`
+	ExpiresAt int64  `json:"expiresAt,omitempty"`
 }
 
 // NewApprovalEvent is ...
-func NewApprovalEvent(owner, spender string, amount int) *ApprovalEvent {
-	return &ApprovalEvent{owner, spender, amount}
+func NewApprovalEvent(owner, spender string, amount int, expiresAt int64) *ApprovalEvent {
+	return &ApprovalEvent{owner, spender, amount, expiresAt}
 }
@@ -0,0 +1,40 @@
+package model
+
+// AuditIssueType is ...
+const (
+	MalformedBalanceIssue   = "malformedBalance"
+	NegativeBalanceIssue    = "negativeBalance"
+	MalformedAllowanceIssue = "malformedAllowance"
+	NegativeAllowanceIssue  = "negativeAllowance"
+	OrphanedAllowanceIssue  = "orphanedAllowance"
+	SupplyMismatchIssue     = "supplyMismatch"
+)
+
+// AuditIssue is the ledger entry failing the audit
+type AuditIssue struct {
+	Type    string `json:"type"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// AuditReport is the page of the audit of the balances and the allowances against the total supply.
+// The sums and the counts include the previous pages, the issues are found in this page.
+// Consistent is true on the last page if no page found an issue.
+type AuditReport struct {
+	TokenName      string       `json:"tokenName"`
+	TotalSupply    int          `json:"totalSupply"`
+	BalanceSum     int          `json:"balanceSum"`
+	BalanceCount   int          `json:"balanceCount"`
+	AllowanceCount int          `json:"allowanceCount"`
+	IssueCount     int          `json:"issueCount"`
+	Issues         []AuditIssue `json:"issues"`
+	Complete       bool         `json:"complete"`
+	Consistent     bool         `json:"consistent"`
+	Bookmark       string       `json:"bookmark"`
+}
+
+// AuditCursor is the position of the next page carried by the bookmark
+type AuditCursor struct {
+	Key string `json:"key"`
+}
@@ -5,6 +5,8 @@ import "fmt"
 // ConvertErrorType is ...
 const (
 	ConvertErrorType = "Convert"
+	GetErrorType     = "Get"
+	VerifyErrorType  = "Verify"
 )
 
 // CustomError is ...
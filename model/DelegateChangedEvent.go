@@ -0,0 +1,13 @@
+package model
+
+// DelegateChangedEvent is the log of the DelegateChangedEvent
+type DelegateChangedEvent struct {
+	Delegator    string `json:"delegator"`
+	FromDelegate string `json:"fromDelegate"`
+	ToDelegate   string `json:"toDelegate"`
+}
+
+// NewDelegateChangedEvent is ...
+func NewDelegateChangedEvent(delegator, fromDelegate, toDelegate string) *DelegateChangedEvent {
+	return &DelegateChangedEvent{delegator, fromDelegate, toDelegate}
+}
@@ -0,0 +1,24 @@
+package model
+
+// Distribution is the definition of the pro-rata payout to the holders of a snapshot
+type Distribution struct {
+	ID            string `json:"id"`
+	TokenName     string `json:"tokenName"`
+	SnapshotID    int    `json:"snapshotId"`
+	Distributor   string `json:"distributor"`
+	Amount        int    `json:"amount"`
+	Claimed       int    `json:"claimed"`
+	ClaimDeadline int64  `json:"claimDeadline"`
+	Closed        bool   `json:"closed"`
+}
+
+// NewDistribution is ...
+func NewDistribution(id, tokenName string, snapshotID int, distributor string, amount int, claimDeadline int64) *Distribution {
+	return &Distribution{
+		ID:            id,
+		TokenName:     tokenName,
+		SnapshotID:    snapshotID,
+		Distributor:   distributor,
+		Amount:        amount,
+		ClaimDeadline: claimDeadline}
+}
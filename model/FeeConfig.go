@@ -0,0 +1,19 @@
+package model
+
+// FeeConfig is the definition of the transfer fee schedule.
+// fee = flat + amount * basisPoints / 10000, bounded by min and max(0 means no max)
+type FeeConfig struct {
+	Flat        int      `json:"flat"`
+	BasisPoints int      `json:"basisPoints"`
+	Min         int      `json:"min"`
+	Max         int      `json:"max"`
+	Collector   string   `json:"collector"`
+	Exempt      []string `json:"exempt"`
+}
+
+// TransferQuote is the net and fee amounts of the transfer
+type TransferQuote struct {
+	Amount int `json:"amount"`
+	Net    int `json:"net"`
+	Fee    int `json:"fee"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// GovernanceConfig is the definition of the governance parameters
+type GovernanceConfig struct {
+	Quorum            int   `json:"quorum"`
+	Threshold         int   `json:"threshold"`
+	VotingPeriod      int64 `json:"votingPeriod"`
+	ProposalThreshold int   `json:"proposalThreshold"`
+}
+
+// NewGovernanceConfig is ...
+// Returns the default parameters: no quorum, simple majority, 3 days of voting, /
+// proposed by the holders of 1% of the total supply
+func NewGovernanceConfig() *GovernanceConfig {
+	return &GovernanceConfig{Quorum: 0, Threshold: 5000, VotingPeriod: 3 * 24 * 60 * 60, ProposalThreshold: 100}
+}
@@ -0,0 +1,18 @@
+package model
+
+// KeyRotation is the canonical message signed by the old key to register the new key. /
+// Channel, chaincode and token are the domain of the rotation.
+type KeyRotation struct {
+	Function  string `json:"function"`
+	Channel   string `json:"channel"`
+	Chaincode string `json:"chaincode"`
+	Token     string `json:"token"`
+	Address   string `json:"address"`
+	NewKey    string `json:"newKey"`
+	Nonce     string `json:"nonce"`
+}
+
+// NewKeyRotation is ...
+func NewKeyRotation(channel, chaincode, token, address, newKey, nonce string) *KeyRotation {
+	return &KeyRotation{"rotateKey", channel, chaincode, token, address, newKey, nonce}
+}
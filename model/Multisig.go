@@ -0,0 +1,18 @@
+package model
+
+// Multisig is the definition of the account moved by the confirmations of its signers
+type Multisig struct {
+	Address   string   `json:"address"`
+	Signers   []string `json:"signers"`
+	Threshold int      `json:"threshold"`
+}
+
+// MultisigTransfer is the transfer proposed from the multisig account
+type MultisigTransfer struct {
+	ID            string   `json:"id"`
+	Multisig      string   `json:"multisig"`
+	Recipient     string   `json:"recipient"`
+	Amount        int      `json:"amount"`
+	Confirmations []string `json:"confirmations"`
+	Executed      bool     `json:"executed"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// Permit is the canonical message signed by the owner to approve the spender off-chain. /
+// Channel, chaincode and token are the domain of the permit like the domain separator of EIP-2612.
+type Permit struct {
+	Function  string `json:"function"`
+	Channel   string `json:"channel"`
+	Chaincode string `json:"chaincode"`
+	Token     string `json:"token"`
+	Owner     string `json:"owner"`
+	Spender   string `json:"spender"`
+	Amount    string `json:"amount"`
+	Nonce     string `json:"nonce"`
+	Deadline  string `json:"deadline"`
+}
+
+// NewPermit is ...
+func NewPermit(channel, chaincode, token, owner, spender, amount, nonce, deadline string) *Permit {
+	return &Permit{"permit", channel, chaincode, token, owner, spender, amount, nonce, deadline}
+}
@@ -0,0 +1,8 @@
+package model
+
+// PrivateBalance is the record of the balance in the private data collection.
+// The peers publish the hash of the record, so the nonce keeps the balance from being guessed by the hash.
+type PrivateBalance struct {
+	Balance int    `json:"balance"`
+	Nonce   string `json:"nonce"`
+}
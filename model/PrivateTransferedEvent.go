@@ -0,0 +1,14 @@
+package model
+
+// PrivateTransferedEvent is the log of the PrivateTransferedEvent.
+// Hash is the salted hash of the transfer, the amount is not revealed.
+type PrivateTransferedEvent struct {
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Hash      string `json:"hash"`
+}
+
+// NewPrivateTransferedEvent is ...
+func NewPrivateTransferedEvent(sender, recipient, hash string) *PrivateTransferedEvent {
+	return &PrivateTransferedEvent{sender, recipient, hash}
+}
@@ -0,0 +1,29 @@
+package model
+
+// Proposal is the definition of the governance proposal
+type Proposal struct {
+	ID           string            `json:"id"`
+	TokenName    string            `json:"tokenName"`
+	Proposer     string            `json:"proposer"`
+	Description  string            `json:"description"`
+	Action       ProposalAction    `json:"action"`
+	Config       *GovernanceConfig `json:"config,omitempty"`
+	SnapshotID   int               `json:"snapshotId"`
+	Start        int64             `json:"start"`
+	End          int64             `json:"end"`
+	ForVotes     int               `json:"forVotes"`
+	AgainstVotes int               `json:"againstVotes"`
+	Executed     bool              `json:"executed"`
+}
+
+// ProposalAction is the whitelisted function executed when the proposal passes
+type ProposalAction struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+// ProposalPage is the page of the proposal list
+type ProposalPage struct {
+	Proposals []Proposal `json:"proposals"`
+	Bookmark  string     `json:"bookmark"`
+}
@@ -0,0 +1,19 @@
+package model
+
+// SchemaVersion is the record of the state layout version of the deployed token
+type SchemaVersion struct {
+	Version    int    `json:"version"`
+	TokenName  string `json:"tokenName"`
+	TxID       string `json:"txId"`
+	UpgradedAt int64  `json:"upgradedAt"`
+}
+
+// UpgradeStatus is the result of the upgradeStatus query
+type UpgradeStatus struct {
+	SchemaVersion       int      `json:"schemaVersion"`
+	LatestSchemaVersion int      `json:"latestSchemaVersion"`
+	PendingMigrations   []string `json:"pendingMigrations"`
+	TokenName           string   `json:"tokenName,omitempty"`
+	LastUpgradeTxID     string   `json:"lastUpgradeTxId,omitempty"`
+	LastUpgradedAt      int64    `json:"lastUpgradedAt,omitempty"`
+}
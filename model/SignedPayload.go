@@ -0,0 +1,15 @@
+package model
+
+// SignedPayload is the message signed by the user to invoke a function through a relayer.
+// Args are the params of the function without the acting account, which is the signer. /
+// Channel, chaincode and token are the domain of the payload.
+type SignedPayload struct {
+	Channel   string   `json:"channel"`
+	Chaincode string   `json:"chaincode"`
+	Token     string   `json:"token"`
+	Signer    string   `json:"signer"`
+	Function  string   `json:"function"`
+	Args      []string `json:"args"`
+	Nonce     int      `json:"nonce"`
+	Expiry    int64    `json:"expiry"`
+}
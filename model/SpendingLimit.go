@@ -0,0 +1,14 @@
+package model
+
+// SpendingLimit is the definition of the outflow caps of the account, 0 means no limit
+type SpendingLimit struct {
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
+
+// RemainingLimit is the amount the account can still send in the current day and month, /
+// -1 means no limit
+type RemainingLimit struct {
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
@@ -5,9 +5,10 @@ type TransferedEvent struct {
 	Sender          string `json:"sender"`
 	Recipient       string `json:"recipient"`
 	TransferedMoney string `json:"transferedMoney"`
+	Fee             string `json:"fee,omitempty"`
 }
 
 // newTransferedEvent is ...
-func newTransferedEvent(sender, recipient, transferedMoney string) *TransferedEvent {
-	return &TransferedEvent{sender, recipient, transferedMoney}
+func newTransferedEvent(sender, recipient, transferedMoney, fee string) *TransferedEvent {
+	return &TransferedEvent{sender, recipient, transferedMoney, fee}
 }
@@ -0,0 +1,28 @@
+package model
+
+// Vesting is the definition of the token vesting schedule
+type Vesting struct {
+	ID          string `json:"id"`
+	Grantor     string `json:"grantor"`
+	Beneficiary string `json:"beneficiary"`
+	Total       int    `json:"total"`
+	Released    int    `json:"released"`
+	Start       int64  `json:"start"`
+	Cliff       int64  `json:"cliff"`
+	Duration    int64  `json:"duration"`
+	Revocable   bool   `json:"revocable"`
+	Revoked     bool   `json:"revoked"`
+}
+
+// NewVesting is ...
+func NewVesting(id, grantor, beneficiary string, total int, start, cliff, duration int64, revocable bool) *Vesting {
+	return &Vesting{
+		ID:          id,
+		Grantor:     grantor,
+		Beneficiary: beneficiary,
+		Total:       total,
+		Start:       start,
+		Cliff:       cliff,
+		Duration:    duration,
+		Revocable:   revocable}
+}
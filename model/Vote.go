@@ -0,0 +1,15 @@
+package model
+
+// Vote is the log of the vote cast on the proposal
+type Vote struct {
+	ProposalID string `json:"proposalId"`
+	Voter      string `json:"voter"`
+	Support    bool   `json:"support"`
+	Weight     int    `json:"weight"`
+}
+
+// VotePage is the page of the vote list
+type VotePage struct {
+	Votes    []Vote `json:"votes"`
+	Bookmark string `json:"bookmark"`
+}
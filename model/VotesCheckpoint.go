@@ -0,0 +1,7 @@
+package model
+
+// VotesCheckpoint is the voting power of a delegatee from the timestamp
+type VotesCheckpoint struct {
+	Timestamp int64 `json:"timestamp"`
+	Votes     int   `json:"votes"`
+}
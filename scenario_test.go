@@ -0,0 +1,531 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hypherledgertest2/mockledger"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+	"gopkg.in/yaml.v2"
+)
+
+// scenarioStart is the transaction time of the first step of every scenario
+var scenarioStart = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Scenario is the declarative test case loaded from a YAML or JSON file.
+// The strings of the args, the payloads and the balances can use the placeholders:
+// ${name} the address of the identity, ${name.key} the PEM public key of the identity's signing key, /
+// ${name.key2} the second signing key for the key rotation, ${now} or ${now+1h} the unix time of the step, /
+// ${channel} the channel id, ${chaincode} the chaincode name and ${saved} the payload saved by a previous step.
+// ${placeholder:json} escapes the value to be embedded in a json string of the args.
+type Scenario struct {
+	Name       string              `yaml:"name" json:"name"`
+	Init       []string            `yaml:"init" json:"init"`
+	Identities []string            `yaml:"identities" json:"identities"`
+	Chaincodes []ScenarioChaincode `yaml:"chaincodes" json:"chaincodes"`
+	Steps      []ScenarioStep      `yaml:"steps" json:"steps"`
+	Balances   map[string]int      `yaml:"balances" json:"balances"`
+}
+
+// ScenarioChaincode is the mock chaincode called back by the token, it rejects every call if Reject is set
+type ScenarioChaincode struct {
+	Name   string `yaml:"name" json:"name"`
+	Reject bool   `yaml:"reject" json:"reject"`
+}
+
+// ScenarioStep is the invocation of a function and its expected result.
+// The writes and the events of a failed step are discarded as the peer would, /
+// the clock is moved by Advance(a duration like 1h) before the step.
+type ScenarioStep struct {
+	Name      string            `yaml:"name" json:"name"`
+	Invoke    string            `yaml:"invoke" json:"invoke"`
+	As        string            `yaml:"as" json:"as"`
+	Args      []string          `yaml:"args" json:"args"`
+	Transient map[string]string `yaml:"transient" json:"transient"`
+	Advance   string            `yaml:"advance" json:"advance"`
+	Sign      *ScenarioSign     `yaml:"sign" json:"sign"`
+	Save      string            `yaml:"save" json:"save"`
+	Expect    ScenarioExpect    `yaml:"expect" json:"expect"`
+}
+
+// ScenarioSign appends the base64 signature of the message by the identity's signing key to the args.
+// The message is the first arg if it is empty, the key is "key" or "key2".
+type ScenarioSign struct {
+	Identity string `yaml:"identity" json:"identity"`
+	Key      string `yaml:"key" json:"key"`
+	Message  string `yaml:"message" json:"message"`
+}
+
+// ScenarioExpect is the expected response of the step, the status is 200 if it is not set.
+// The payload is compared as is if it is a string, or as the subset of the json payload otherwise.
+// The message is a substring of the error message.
+type ScenarioExpect struct {
+	Status  int32           `yaml:"status" json:"status"`
+	Payload interface{}     `yaml:"payload" json:"payload"`
+	Message string          `yaml:"message" json:"message"`
+	Events  []ScenarioEvent `yaml:"events" json:"events"`
+}
+
+// ScenarioEvent is the expected event emitted by the step
+type ScenarioEvent struct {
+	Name    string      `yaml:"name" json:"name"`
+	Payload interface{} `yaml:"payload" json:"payload"`
+}
+
+// scenarioChaincode is the mock chaincode receiving the callbacks of the token
+type scenarioChaincode struct {
+	reject bool
+}
+
+func (c *scenarioChaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (c *scenarioChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	if c.reject {
+		return shim.Error("rejected by the mock chaincode")
+	}
+
+	return shim.Success(nil)
+}
+
+// scenarioIdentity is the address and the signing keys of an identity of the mock ledger
+type scenarioIdentity struct {
+	address string
+	keys    map[string]ed25519.PrivateKey
+}
+
+// scenarioRun is the execution of a scenario against the token of a new mock ledger
+type scenarioRun struct {
+	t          *testing.T
+	ledger     *mockledger.Ledger
+	now        time.Time
+	identities map[string]*scenarioIdentity
+	saved      map[string]string
+}
+
+var placeholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// expand replaces the placeholders of the string
+func (r *scenarioRun) expand(s string) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholder[2 : len(placeholder)-1]
+
+		// ${...:json} escapes the value to be embedded in a json string
+		if strings.HasSuffix(name, ":json") {
+			escaped, err := json.Marshal(r.expand("${" + strings.TrimSuffix(name, ":json") + "}"))
+			if err != nil {
+				r.t.Fatal(err)
+			}
+			return string(escaped[1 : len(escaped)-1])
+		}
+
+		switch {
+		case name == "channel":
+			return r.ledger.MockStub().ChannelID
+		case name == "chaincode":
+			return r.ledger.MockStub().Name
+		case name == "now":
+			return strconv.FormatInt(r.now.Unix(), 10)
+		case strings.HasPrefix(name, "now+"), strings.HasPrefix(name, "now-"):
+			duration, err := time.ParseDuration(name[3:])
+			if err != nil {
+				r.t.Fatalf("invalid duration of %s", placeholder)
+			}
+			return strconv.FormatInt(r.now.Add(duration).Unix(), 10)
+		}
+
+		if value, ok := r.saved[name]; ok {
+			return value
+		}
+
+		identityName, keyName := name, ""
+		if i := strings.Index(name, "."); i >= 0 {
+			identityName, keyName = name[:i], name[i+1:]
+		}
+		identity, ok := r.identities[identityName]
+		if !ok {
+			r.t.Fatalf("unknown placeholder %s", placeholder)
+		}
+		if keyName == "" {
+			return identity.address
+		}
+
+		return r.publicKeyPEM(identity, keyName)
+	})
+}
+
+// expandValue replaces the placeholders of the strings in the decoded yaml or json value
+func (r *scenarioRun) expandValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.expand(v)
+	case map[interface{}]interface{}:
+		expanded := map[string]interface{}{}
+		for key, item := range v {
+			expanded[r.expand(fmt.Sprint(key))] = r.expandValue(item)
+		}
+		return expanded
+	case map[string]interface{}:
+		expanded := map[string]interface{}{}
+		for key, item := range v {
+			expanded[r.expand(key)] = r.expandValue(item)
+		}
+		return expanded
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded[i] = r.expandValue(item)
+		}
+		return expanded
+	default:
+		return v
+	}
+}
+
+func (r *scenarioRun) publicKeyPEM(identity *scenarioIdentity, keyName string) string {
+	key, ok := identity.keys[keyName]
+	if !ok {
+		r.t.Fatalf("unknown key %s", keyName)
+	}
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+}
+
+func newScenarioRun(t *testing.T, scenario *Scenario) *scenarioRun {
+	r := &scenarioRun{
+		t:          t,
+		ledger:     mockledger.New(),
+		now:        scenarioStart,
+		identities: map[string]*scenarioIdentity{},
+		saved:      map[string]string{},
+	}
+
+	for _, peer := range scenario.Chaincodes {
+		r.ledger.DeployChaincode(peer.Name, &scenarioChaincode{peer.Reject})
+	}
+
+	for _, name := range scenario.Identities {
+		identity := &scenarioIdentity{keys: map[string]ed25519.PrivateKey{}}
+		for _, keyName := range []string{"key", "key2"} {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			identity.keys[keyName] = key
+		}
+		r.identities[name] = identity
+		identity.address = string(r.invoke(&ScenarioStep{Invoke: "callerAddress", As: name}).Payload)
+	}
+
+	return r
+}
+
+// invoke runs the step as a transaction of the identity,
+// the writes and the events of a failed transaction are discarded by the mock ledger
+func (r *scenarioRun) invoke(step *ScenarioStep) *mockledger.Result {
+	if _, ok := r.identities[step.As]; step.As != "" && !ok {
+		r.t.Fatalf("unknown identity %s", step.As)
+	}
+
+	params := make([]string, len(step.Args))
+	for i, arg := range step.Args {
+		params[i] = r.expand(arg)
+	}
+
+	if step.Sign != nil {
+		params = append(params, r.sign(step.Sign, params))
+	}
+
+	var transient map[string][]byte
+	if step.Transient != nil {
+		transient = map[string][]byte{}
+		for key, value := range step.Transient {
+			transient[key] = []byte(r.expand(value))
+		}
+	}
+
+	tx := mockledger.Transaction{Identity: step.As, Function: step.Invoke, Args: params, Transient: transient, Time: r.now}
+	var res *mockledger.Result
+	var err error
+	if step.Invoke == "init" {
+		res, err = r.ledger.Init(tx)
+	} else {
+		res, err = r.ledger.Invoke(tx)
+	}
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	return res
+}
+
+// sign signs the message by the identity's signing key
+func (r *scenarioRun) sign(sign *ScenarioSign, params []string) string {
+	identity, ok := r.identities[sign.Identity]
+	if !ok {
+		r.t.Fatalf("unknown identity %s", sign.Identity)
+	}
+
+	keyName := sign.Key
+	if keyName == "" {
+		keyName = "key"
+	}
+	key, ok := identity.keys[keyName]
+	if !ok {
+		r.t.Fatalf("unknown key %s", keyName)
+	}
+
+	message := r.expand(sign.Message)
+	if sign.Message == "" && len(params) > 0 {
+		message = params[0]
+	}
+
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(message)))
+}
+
+// runStep runs the step and reports the differences from the expected result
+func (r *scenarioRun) runStep(i int, step *ScenarioStep) {
+	label := fmt.Sprintf("step %d %s(%s)", i+1, step.Invoke, step.Name)
+
+	if step.Advance != "" {
+		duration, err := time.ParseDuration(step.Advance)
+		if err != nil {
+			r.t.Fatalf("%s: invalid advance %s", label, step.Advance)
+		}
+		r.now = r.now.Add(duration)
+	}
+
+	res := r.invoke(step)
+	events := res.Events
+
+	expectedStatus := step.Expect.Status
+	if expectedStatus == 0 {
+		expectedStatus = shim.OK
+	}
+	if res.Status != expectedStatus {
+		r.t.Errorf("%s: expected status %d, got %d: %s", label, expectedStatus, res.Status, res.Message)
+		return
+	}
+
+	if step.Expect.Message != "" && !strings.Contains(res.Message, r.expand(step.Expect.Message)) {
+		r.t.Errorf("%s: expected message containing %q, got %q", label, r.expand(step.Expect.Message), res.Message)
+	}
+
+	if step.Expect.Payload != nil {
+		if diff := r.diffPayload(step.Expect.Payload, res.Payload); diff != "" {
+			r.t.Errorf("%s: payload %s", label, diff)
+		}
+	}
+
+	if step.Expect.Events != nil {
+		if len(events) != len(step.Expect.Events) {
+			r.t.Errorf("%s: expected %d events, got %d", label, len(step.Expect.Events), len(events))
+		} else {
+			for j, expected := range step.Expect.Events {
+				if events[j].Name != expected.Name {
+					r.t.Errorf("%s: event %d: expected %s, got %s", label, j+1, expected.Name, events[j].Name)
+					continue
+				}
+				if expected.Payload == nil {
+					continue
+				}
+				if diff := r.diffPayload(expected.Payload, events[j].Payload); diff != "" {
+					r.t.Errorf("%s: event %d %s: payload %s", label, j+1, expected.Name, diff)
+				}
+			}
+		}
+	}
+
+	if step.Save != "" {
+		r.saved[step.Save] = string(res.Payload)
+	}
+}
+
+// diffPayload compares the payload with the expected string, or the expected subset of the json
+func (r *scenarioRun) diffPayload(expected interface{}, payload []byte) string {
+	if expectedString, ok := expected.(string); ok {
+		expectedString = r.expand(expectedString)
+		if expectedString != string(payload) {
+			return fmt.Sprintf("expected %q, got %q", expectedString, payload)
+		}
+		return ""
+	}
+
+	var actual interface{}
+	if err := json.Unmarshal(payload, &actual); err != nil {
+		return fmt.Sprintf("expected json, got %q", payload)
+	}
+
+	// normalize the numbers of yaml to the numbers of json
+	expectedBytes, err := json.Marshal(r.expandValue(expected))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	var expectedJSON interface{}
+	json.Unmarshal(expectedBytes, &expectedJSON)
+
+	return diffJSON("", expectedJSON, actual)
+}
+
+// diffJSON reports the first difference of the actual json from the expected subset
+func diffJSON(path string, expected, actual interface{}) string {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("at %s: expected an object, got %v", path, actual)
+		}
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if diff := diffJSON(path+"."+key, e[key], a[key]); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return fmt.Sprintf("at %s: expected %d items, got %v", path, len(e), actual)
+		}
+		for i := range e {
+			if diff := diffJSON(path+"["+strconv.Itoa(i)+"]", e[i], a[i]); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	default:
+		if !reflect.DeepEqual(expected, actual) && jsonScalar(expected) != jsonScalar(actual) {
+			return fmt.Sprintf("at %s: expected %v, got %v", path, expected, actual)
+		}
+		return ""
+	}
+}
+
+// jsonScalar formats the json scalar, so that the expanded placeholders match the numbers
+func jsonScalar(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// checkBalances reports the differences of the final balances
+func (r *scenarioRun) checkBalances(balances map[string]int) {
+	for address, expected := range balances {
+		address = r.expand(address)
+
+		balance := 0
+		if balanceBytes, ok := r.ledger.MockStub().State[address]; ok {
+			balance, _ = strconv.Atoi(string(balanceBytes))
+		}
+		if balance != expected {
+			r.t.Errorf("balance of %s: expected %d, got %d", address, expected, balance)
+		}
+	}
+}
+
+// loadScenario loads the scenario from the YAML or JSON file
+func loadScenario(path string) (*Scenario, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := &Scenario{}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(content, scenario)
+	} else {
+		err = yaml.UnmarshalStrict(content, scenario)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return scenario, nil
+}
+
+// runScenario runs the init and the steps of the scenario, then checks the final balances
+func runScenario(t *testing.T, scenario *Scenario) {
+	r := newScenarioRun(t, scenario)
+
+	if scenario.Init != nil {
+		res := r.invoke(&ScenarioStep{Invoke: "init", Args: scenario.Init})
+		if res.Status != shim.OK {
+			t.Fatalf("init failed: %s", res.Message)
+		}
+	}
+
+	for i := range scenario.Steps {
+		r.runStep(i, &scenario.Steps[i])
+	}
+
+	r.checkBalances(scenario.Balances)
+}
+
+// TestScenarios runs the scenario files of testdata/scenarios, /
+// set SCENARIOS to the glob of other files.
+func TestScenarios(t *testing.T) {
+	pattern := os.Getenv("SCENARIOS")
+	if pattern == "" {
+		pattern = filepath.Join("testdata", "scenarios", "*")
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no scenario matches %s", pattern)
+	}
+
+	for _, path := range paths {
+		scenario, err := loadScenario(path)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		name := filepath.Base(path)
+		if scenario.Name != "" {
+			name += "/" + scenario.Name
+		}
+		t.Run(name, func(t *testing.T) {
+			runScenario(t, scenario)
+		})
+	}
+}
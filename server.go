@@ -0,0 +1,115 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"errors"
+	"hypherledgertest2/fabric"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// loadServerConfig loads the config of the chaincode server from the environment variables /
+// CHAINCODE_SERVER_ADDRESS, CHAINCODE_ID, CHAINCODE_TLS_DISABLED, /
+// and the file paths CHAINCODE_TLS_KEY, CHAINCODE_TLS_CERT, CHAINCODE_CLIENT_CA_CERT.
+func loadServerConfig() (*fabric.ServerConfig, error) {
+	config := &fabric.ServerConfig{
+		Address: os.Getenv("CHAINCODE_SERVER_ADDRESS"),
+		CCID:    os.Getenv("CHAINCODE_ID"),
+	}
+
+	if config.Address == "" || config.CCID == "" {
+		return nil, errors.New("CHAINCODE_SERVER_ADDRESS and CHAINCODE_ID must be set")
+	}
+
+	// TLS is enabled unless it is disabled explicitly
+	tlsDisabled := os.Getenv("CHAINCODE_TLS_DISABLED")
+	if tlsDisabled != "" {
+		disabled, err := strconv.ParseBool(tlsDisabled)
+		if err != nil {
+			return nil, errors.New("CHAINCODE_TLS_DISABLED must be a boolean")
+		}
+		config.TLSDisabled = disabled
+	}
+	if config.TLSDisabled {
+		return config, nil
+	}
+
+	var err error
+	config.TLSKey, err = readEnvFile("CHAINCODE_TLS_KEY", true)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSCert, err = readEnvFile("CHAINCODE_TLS_CERT", true)
+	if err != nil {
+		return nil, err
+	}
+
+	// the client certificate is verified only when the CA is set
+	config.TLSClientCACert, err = readEnvFile("CHAINCODE_CLIENT_CA_CERT", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// readEnvFile reads the file at the path of the environment variable, nil if it is not set
+func readEnvFile(name string, required bool) ([]byte, error) {
+	path := os.Getenv(name)
+	if path == "" {
+		if required {
+			return nil, errors.New(name + " must be set when TLS is enabled")
+		}
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("failed to read " + name + ", err: " + err.Error())
+	}
+
+	return content, nil
+}
+
+// healthDialTimeout is the timeout of the readiness check of the chaincode server
+const healthDialTimeout = time.Second
+
+// healthHandler answers the liveness and readiness probes of the chaincode server, /
+// ready only while the chaincode server is listening at its address
+func healthHandler(serverAddress string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		conn, err := net.DialTimeout("tcp", serverAddress, healthDialTimeout)
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"NOT_READY"}`))
+			return
+		}
+		conn.Close()
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"OK"}`))
+	}
+}
+
+// serveHealth serves the health endpoint /healthz at the address /
+// for the chaincode server at the server address
+func serveHealth(address, serverAddress string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthHandler(serverAddress))
+
+	return http.ListenAndServe(address, mux)
+}
@@ -0,0 +1,118 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var serverEnvNames = []string{"CHAINCODE_SERVER_ADDRESS", "CHAINCODE_ID", "CHAINCODE_TLS_DISABLED",
+	"CHAINCODE_TLS_KEY", "CHAINCODE_TLS_CERT", "CHAINCODE_CLIENT_CA_CERT"}
+
+// setServerEnv sets the environment variables of the chaincode server, unsetting the others
+func setServerEnv(env map[string]string) {
+	for _, name := range serverEnvNames {
+		os.Unsetenv(name)
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+}
+
+func TestLoadServerConfigWithoutTLS(t *testing.T) {
+	defer setServerEnv(nil)
+	setServerEnv(map[string]string{
+		"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999",
+		"CHAINCODE_ID":             "erc20:abc",
+		"CHAINCODE_TLS_DISABLED":   "true",
+	})
+
+	config, err := loadServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Address != "0.0.0.0:9999" || config.CCID != "erc20:abc" || !config.TLSDisabled {
+		t.Fatalf("unexpected config %+v", config)
+	}
+}
+
+func TestLoadServerConfigWithTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer setServerEnv(nil)
+
+	keyPath, certPath := filepath.Join(dir, "key.pem"), filepath.Join(dir, "cert.pem")
+	ioutil.WriteFile(keyPath, []byte("key"), 0600)
+	ioutil.WriteFile(certPath, []byte("cert"), 0600)
+
+	setServerEnv(map[string]string{
+		"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999",
+		"CHAINCODE_ID":             "erc20:abc",
+		"CHAINCODE_TLS_KEY":        keyPath,
+		"CHAINCODE_TLS_CERT":       certPath,
+	})
+
+	config, err := loadServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TLSDisabled || string(config.TLSKey) != "key" || string(config.TLSCert) != "cert" || config.TLSClientCACert != nil {
+		t.Fatalf("unexpected config %+v", config)
+	}
+}
+
+func TestLoadServerConfigErrors(t *testing.T) {
+	defer setServerEnv(nil)
+	envs := []map[string]string{
+		{"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999"},
+		{"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999", "CHAINCODE_ID": "erc20:abc", "CHAINCODE_TLS_DISABLED": "maybe"},
+		{"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999", "CHAINCODE_ID": "erc20:abc"},
+		{"CHAINCODE_SERVER_ADDRESS": "0.0.0.0:9999", "CHAINCODE_ID": "erc20:abc", "CHAINCODE_TLS_KEY": "/nonexistent", "CHAINCODE_TLS_CERT": "/nonexistent"},
+	}
+
+	for _, env := range envs {
+		setServerEnv(env)
+		if _, err := loadServerConfig(); err == nil {
+			t.Fatalf("config must fail with %v", env)
+		}
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := healthHandler(listener.Addr().String())
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/healthz", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", recorder.Code)
+	}
+
+	// not ready once the chaincode server is not listening
+	listener.Close()
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,147 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hypherledgertest2/mockledger"
+	"hypherledgertest2/model"
+)
+
+// signedFixture is the token owned by alice, who registered the signing key, /
+// the signed payloads of alice are relayed by the identity "relayer"
+type signedFixture struct {
+	*erc20Fixture
+	alice string
+	key   ed25519.PrivateKey
+	nonce int
+}
+
+func newSignedFixture(t *testing.T) *signedFixture {
+	f := &erc20Fixture{t: t, ledger: mockledger.New()}
+	s := &signedFixture{erc20Fixture: f, alice: f.address("alice")}
+	f.init(s.alice, 1000)
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.key = key
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mustInvoke("alice", "registerKey", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})))
+
+	return s
+}
+
+// executeSigned relays the function signed by alice through the chaincode entry point
+func (s *signedFixture) executeSigned(function string, args ...string) *mockledger.Result {
+	payload := model.SignedPayload{
+		Channel:   s.ledger.MockStub().ChannelID,
+		Chaincode: s.ledger.MockStub().Name,
+		Token:     "token",
+		Signer:    s.alice,
+		Function:  function,
+		Args:      args,
+		Nonce:     s.nonce,
+		Expiry:    time.Now().Add(time.Hour).Unix()}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	res := s.invoke("relayer", "executeSigned", string(payloadBytes), base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payloadBytes)))
+	if res.OK() {
+		s.nonce++
+	}
+
+	return res
+}
+
+func TestExecuteSignedFunctions(t *testing.T) {
+	s := newSignedFixture(t)
+	s.ledger.DeployChaincode("receiver", &scenarioChaincode{})
+	s.mustInvoke("", "transfer", s.alice, "bob", "100")
+	s.mustInvoke("", "approve", "bob", s.alice, "50")
+	s.mustInvoke("alice", "setGovernanceConfig", "token", "100", "5000", "3600", "100")
+	s.mustInvoke("alice", "delegate", s.alice)
+	proposalID := string(s.mustInvoke("alice", "propose", "token", "pause the token", `{"function": "pause", "args": []}`))
+	expiresAt := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	// every function accepted by executeSigned acts for alice, not for the relayer
+	for _, test := range []struct {
+		function string
+		args     []string
+		check    func()
+	}{
+		{"transfer", []string{"carol", "10"}, func() { s.expectBalance("carol", 10) }},
+		{"approve", []string{"spender", "20"}, func() { s.expectAllowance(s.alice, "spender", 20) }},
+		{"approveWithExpiry", []string{"expiring", "30", expiresAt}, func() { s.expectAllowance(s.alice, "expiring", 30) }},
+		{"increaseAllowance", []string{"spender", "5"}, func() { s.expectAllowance(s.alice, "spender", 25) }},
+		{"decreaseAllowance", []string{"spender", "10"}, func() { s.expectAllowance(s.alice, "spender", 15) }},
+		{"transferAndCall", []string{"receiver", "40", "order-1"}, func() { s.expectBalance("receiver", 40) }},
+		{"approveAndCall", []string{"receiver", "60", "order-2"}, func() { s.expectAllowance(s.alice, "receiver", 60) }},
+		{"transferFrom", []string{"bob", "dave", "50"}, func() { s.expectBalance("dave", 50) }},
+		{"burn", []string{"token", "50"}, func() { s.expectBalance(s.alice, 800) }},
+		{"delegate", []string{"bob"}, func() {
+			if delegatee := string(s.mustInvoke("", "delegates", s.alice)); delegatee != "bob" {
+				t.Fatalf("expected the delegatee of alice bob, got %s", delegatee)
+			}
+		}},
+		{"castVote", []string{proposalID, "true"}, func() {
+			s.mustFail("alice", "castVote", proposalID, "true")
+		}},
+	} {
+		if res := s.executeSigned(test.function, test.args...); !res.OK() {
+			t.Fatalf("signed %s%q failed: %s", test.function, test.args, res.Message)
+		}
+		test.check()
+	}
+	relayerAddress := s.address("relayer")
+	s.mustFail("", "balanceOf", relayerAddress)
+	s.expectAllowance(relayerAddress, "spender", 0)
+	s.expectAllowance("bob", relayerAddress, 0)
+
+	// the functions out of the whitelist are rejected before they are dispatched
+	for _, test := range []struct {
+		function string
+		args     []string
+	}{
+		{"mint", []string{"token", "bob", "10"}},
+		{"executeSigned", []string{"{}", "signature"}},
+		{"registerKey", []string{"key"}},
+		{"permit", []string{s.alice, "spender", "10", "0", expiresAt, "signature"}},
+		{"propose", []string{"token", "mint", `{"function": "mint", "args": ["bob", "10"]}`}},
+		{"setSpendingLimit", []string{"bob", "10", "3600"}},
+		{"privateTransfer", []string{"bob", "10"}},
+		{"transferFromOther", []string{"bob", "dave", "10"}},
+		{"unknown", []string{}},
+	} {
+		res := s.executeSigned(test.function, test.args...)
+		if res.OK() || !strings.Contains(res.Message, "function cannot be invoked with a signed payload") {
+			t.Fatalf("signed %s must be rejected, got %+v", test.function, res)
+		}
+	}
+	s.expectBalance("bob", 50)
+	if nonce := string(s.mustInvoke("", "nonces", s.alice)); nonce != strconv.Itoa(s.nonce) {
+		t.Fatalf("expected the nonce %d, got %s", s.nonce, nonce)
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"hypherledgertest2/model"
+	"sort"
+)
+
+// MemoryStore is the store in memory for the unit tests and the benchmarks of the business logic
+type MemoryStore struct {
+	balances   map[string]int
+	allowances map[string]map[string]model.Allowance
+	metadata   map[string]model.ERC20Metadata
+}
+
+// NewMemoryStore is ...
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		balances:   map[string]int{},
+		allowances: map[string]map[string]model.Allowance{},
+		metadata:   map[string]model.ERC20Metadata{},
+	}
+}
+
+// GetBalance gets the balance of the address, exists is false if it was never saved
+func (s *MemoryStore) GetBalance(address string) (int, bool, error) {
+	balance, exists := s.balances[address]
+	return balance, exists, nil
+}
+
+// PutBalance saves the balance of the address
+func (s *MemoryStore) PutBalance(address string, balance int) error {
+	s.balances[address] = balance
+	return nil
+}
+
+// GetAllowance gets the allowance record, zero if it does not exist
+func (s *MemoryStore) GetAllowance(ownerAddress, spenderAddress string) (*model.Allowance, error) {
+	allowance := s.allowances[ownerAddress][spenderAddress]
+	return &allowance, nil
+}
+
+// PutAllowance saves the allowance record
+func (s *MemoryStore) PutAllowance(ownerAddress, spenderAddress string, allowance *model.Allowance) error {
+	if s.allowances[ownerAddress] == nil {
+		s.allowances[ownerAddress] = map[string]model.Allowance{}
+	}
+
+	s.allowances[ownerAddress][spenderAddress] = *allowance
+	return nil
+}
+
+// ListAllowances gets the allowance records of the owner in the order of the spenders
+func (s *MemoryStore) ListAllowances(ownerAddress string) ([]*AllowanceEntry, error) {
+	entries := []*AllowanceEntry{}
+	for spenderAddress, allowance := range s.allowances[ownerAddress] {
+		allowance := allowance
+		entries = append(entries, &AllowanceEntry{SpenderAddress: spenderAddress, Allowance: &allowance})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].SpenderAddress < entries[j].SpenderAddress
+	})
+
+	return entries, nil
+}
+
+// GetMetadata gets the token metadata, nil if it does not exist
+func (s *MemoryStore) GetMetadata(tokenName string) (*model.ERC20Metadata, error) {
+	erc20, exists := s.metadata[tokenName]
+	if !exists {
+		return nil, nil
+	}
+
+	return &erc20, nil
+}
+
+// PutMetadata saves the token metadata under its name
+func (s *MemoryStore) PutMetadata(erc20 *model.ERC20Metadata) error {
+	s.metadata[erc20.Name] = *erc20
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package store keeps the balances, the allowances and the token metadata /
+// behind typed interfaces, so the storage format is defined in one place /
+// and the business logic can run on the ledger or in memory.
+package store
+
+import "hypherledgertest2/model"
+
+// BalanceStore is the store of the token balances by address
+type BalanceStore interface {
+	// GetBalance gets the balance of the address, exists is false if it was never saved
+	GetBalance(address string) (balance int, exists bool, err error)
+	// PutBalance saves the balance of the address
+	PutBalance(address string, balance int) error
+}
+
+// AllowanceStore is the store of the allowances of the spenders over the owner tokens
+type AllowanceStore interface {
+	// GetAllowance gets the allowance record, zero if it does not exist
+	GetAllowance(ownerAddress, spenderAddress string) (*model.Allowance, error)
+	// PutAllowance saves the allowance record
+	PutAllowance(ownerAddress, spenderAddress string, allowance *model.Allowance) error
+	// ListAllowances gets the allowance records of the owner in the order of the spenders
+	ListAllowances(ownerAddress string) ([]*AllowanceEntry, error)
+}
+
+// MetadataStore is the store of the token metadata
+type MetadataStore interface {
+	// GetMetadata gets the token metadata, nil if it does not exist
+	GetMetadata(tokenName string) (*model.ERC20Metadata, error)
+	// PutMetadata saves the token metadata under its name
+	PutMetadata(erc20 *model.ERC20Metadata) error
+}
+
+// Store is the store of all the token state
+type Store interface {
+	BalanceStore
+	AllowanceStore
+	MetadataStore
+}
+
+// AllowanceEntry is the allowance record of the spender
+type AllowanceEntry struct {
+	SpenderAddress string
+	Allowance      *model.Allowance
+}
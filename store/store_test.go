@@ -0,0 +1,106 @@
+//go:build !fabric2
+// +build !fabric2
+
+package store
+
+import (
+	"hypherledgertest2/model"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// testStore runs the same checks on every implementation of the store
+func testStore(t *testing.T, s Store) {
+	// balances
+	if _, exists, err := s.GetBalance("alice"); err != nil || exists {
+		t.Fatal("balance must not exist before it is saved", err)
+	}
+	if err := s.PutBalance("alice", 100); err != nil {
+		t.Fatal(err)
+	}
+	if balance, exists, err := s.GetBalance("alice"); err != nil || !exists || balance != 100 {
+		t.Fatal("expected the balance 100, got", balance, exists, err)
+	}
+	if err := s.PutBalance("alice", 0); err != nil {
+		t.Fatal(err)
+	}
+	if balance, exists, _ := s.GetBalance("alice"); !exists || balance != 0 {
+		t.Fatal("zero balance must exist, got", balance, exists)
+	}
+
+	// allowances
+	if allowance, err := s.GetAllowance("alice", "bob"); err != nil || allowance.Amount != 0 {
+		t.Fatal("allowance must be zero before it is saved", allowance, err)
+	}
+	s.PutAllowance("alice", "carol", &model.Allowance{Amount: 20, ExpiresAt: 1000})
+	s.PutAllowance("alice", "bob", &model.Allowance{Amount: 10})
+	s.PutAllowance("dave", "bob", &model.Allowance{Amount: 30})
+
+	allowance, err := s.GetAllowance("alice", "carol")
+	if err != nil || allowance.Amount != 20 || allowance.ExpiresAt != 1000 {
+		t.Fatal("expected the allowance 20 expiring at 1000, got", allowance, err)
+	}
+
+	entries, err := s.ListAllowances("alice")
+	if err != nil || len(entries) != 2 {
+		t.Fatal("expected two allowances of alice, got", entries, err)
+	}
+	if entries[0].SpenderAddress != "bob" || entries[0].Allowance.Amount != 10 ||
+		entries[1].SpenderAddress != "carol" || entries[1].Allowance.Amount != 20 {
+		t.Fatal("allowances must be in the order of the spenders, got", entries[0], entries[1])
+	}
+
+	// the returned record is a copy
+	allowance.Amount = 0
+	if allowance, _ := s.GetAllowance("alice", "carol"); allowance.Amount != 20 {
+		t.Fatal("allowance must be changed only by PutAllowance")
+	}
+
+	// metadata
+	if erc20, err := s.GetMetadata("token"); err != nil || erc20 != nil {
+		t.Fatal("metadata must not exist before it is saved", erc20, err)
+	}
+	s.PutMetadata(&model.ERC20Metadata{Name: "token", Symbol: "TKN", Owner: "alice", TotalSupply: 100})
+	if erc20, err := s.GetMetadata("token"); err != nil || erc20 == nil || erc20.TotalSupply != 100 || erc20.Owner != "alice" {
+		t.Fatal("expected the metadata of token, got", erc20, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestStubStore(t *testing.T) {
+	stub := shim.NewMockStub("store", nil)
+	stub.MockTransactionStart("tx")
+	defer stub.MockTransactionEnd("tx")
+
+	testStore(t, NewStubStore(stub))
+}
+
+func TestStubStoreFormat(t *testing.T) {
+	stub := shim.NewMockStub("store", nil)
+	stub.MockTransactionStart("tx")
+	defer stub.MockTransactionEnd("tx")
+	s := NewStubStore(stub)
+
+	// balances are decimal strings under the address
+	s.PutBalance("alice", 42)
+	if string(stub.State["alice"]) != "42" {
+		t.Fatal("balance must be saved as the decimal string, got", string(stub.State["alice"]))
+	}
+
+	// plain amounts saved before allowances could expire are read as the records
+	approvalKey, _ := stub.CreateCompositeKey("approval", []string{"alice", "bob"})
+	stub.PutState(approvalKey, []byte("7"))
+	if allowance, err := s.GetAllowance("alice", "bob"); err != nil || allowance.Amount != 7 || allowance.ExpiresAt != 0 {
+		t.Fatal("expected the legacy allowance 7, got", allowance, err)
+	}
+
+	// malformed balances are reported
+	stub.PutState("broken", []byte("abc"))
+	if _, _, err := s.GetBalance("broken"); err == nil {
+		t.Fatal("malformed balance must fail")
+	}
+}
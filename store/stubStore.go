@@ -0,0 +1,159 @@
+package store
+
+import (
+	"encoding/json"
+	"hypherledgertest2/fabric"
+	"hypherledgertest2/model"
+	"strconv"
+)
+
+// StubStore is the store on the ledger of the transaction.
+// The balances are saved as the decimal string under the address, /
+// the allowances as the json record under the approval composite key /
+// and the metadata as json under the token name.
+type StubStore struct {
+	stub fabric.Stub
+}
+
+// NewStubStore is ...
+func NewStubStore(stub fabric.Stub) *StubStore {
+	return &StubStore{stub}
+}
+
+// GetBalance gets the balance of the address, exists is false if it was never saved
+func (s *StubStore) GetBalance(address string) (int, bool, error) {
+	balanceBytes, err := s.stub.GetState(address)
+	if err != nil {
+		return 0, false, err
+	}
+	if balanceBytes == nil {
+		return 0, false, nil
+	}
+
+	balance, err := strconv.Atoi(string(balanceBytes))
+	if err != nil {
+		return 0, true, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: "balance of " + address,
+			Message:    err.Error()}
+	}
+
+	return balance, true, nil
+}
+
+// PutBalance saves the balance of the address
+func (s *StubStore) PutBalance(address string, balance int) error {
+	return s.stub.PutState(address, []byte(strconv.Itoa(balance)))
+}
+
+// GetAllowance gets the allowance record, zero if it does not exist
+func (s *StubStore) GetAllowance(ownerAddress, spenderAddress string) (*model.Allowance, error) {
+	approvalKey, err := s.stub.CreateCompositeKey("approval", []string{ownerAddress, spenderAddress})
+	if err != nil {
+		return nil, err
+	}
+
+	allowanceBytes, err := s.stub.GetState(approvalKey)
+	if err != nil {
+		return nil, err
+	}
+	if allowanceBytes == nil {
+		return &model.Allowance{}, nil
+	}
+
+	return ParseAllowance(allowanceBytes)
+}
+
+// PutAllowance saves the allowance record
+func (s *StubStore) PutAllowance(ownerAddress, spenderAddress string, allowance *model.Allowance) error {
+	approvalKey, err := s.stub.CreateCompositeKey("approval", []string{ownerAddress, spenderAddress})
+	if err != nil {
+		return err
+	}
+
+	allowanceBytes, err := json.Marshal(allowance)
+	if err != nil {
+		return err
+	}
+
+	return s.stub.PutState(approvalKey, allowanceBytes)
+}
+
+// ListAllowances gets the allowance records of the owner in the order of the spenders
+func (s *StubStore) ListAllowances(ownerAddress string) ([]*AllowanceEntry, error) {
+	approvalIter, err := s.stub.GetStateByPartialCompositeKey("approval", []string{ownerAddress})
+	if err != nil {
+		return nil, err
+	}
+	defer approvalIter.Close()
+
+	entries := []*AllowanceEntry{}
+	for approvalIter.HasNext() {
+		approvalKeyValue, err := approvalIter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, addresses, err := s.stub.SplitCompositeKey(approvalKeyValue.GetKey())
+		if err != nil {
+			return nil, err
+		}
+
+		allowance, err := ParseAllowance(approvalKeyValue.GetValue())
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &AllowanceEntry{SpenderAddress: addresses[1], Allowance: allowance})
+	}
+
+	return entries, nil
+}
+
+// GetMetadata gets the token metadata, nil if it does not exist
+func (s *StubStore) GetMetadata(tokenName string) (*model.ERC20Metadata, error) {
+	erc20Bytes, err := s.stub.GetState(tokenName)
+	if err != nil {
+		return nil, err
+	}
+	if erc20Bytes == nil {
+		return nil, nil
+	}
+
+	erc20 := model.ERC20Metadata{}
+	err = json.Unmarshal(erc20Bytes, &erc20)
+	if err != nil {
+		return nil, err
+	}
+
+	return &erc20, nil
+}
+
+// PutMetadata saves the token metadata under its name
+func (s *StubStore) PutMetadata(erc20 *model.ERC20Metadata) error {
+	erc20Bytes, err := json.Marshal(erc20)
+	if err != nil {
+		return err
+	}
+
+	return s.stub.PutState(erc20.Name, erc20Bytes)
+}
+
+// ParseAllowance parses the allowance record, /
+// or the plain amount saved before allowances could expire
+func ParseAllowance(allowanceBytes []byte) (*model.Allowance, error) {
+	allowance := model.Allowance{}
+
+	amountInt, err := strconv.Atoi(string(allowanceBytes))
+	if err == nil {
+		allowance.Amount = amountInt
+		return &allowance, nil
+	}
+
+	err = json.Unmarshal(allowanceBytes, &allowance)
+	if err != nil {
+		return nil, err
+	}
+
+	return &allowance, nil
+}
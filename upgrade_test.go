@@ -0,0 +1,192 @@
+//go:build !fabric2
+// +build !fabric2
+
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"hypherledgertest2/controller"
+	"hypherledgertest2/mockledger"
+	"hypherledgertest2/model"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// putLegacyState writes the token in the layout saved before the schema version was recorded
+func putLegacyState(t *testing.T, stub *shim.MockStub) {
+	stub.MockTransactionStart("legacy")
+	defer stub.MockTransactionEnd("legacy")
+
+	erc20Bytes, err := json.Marshal(model.ERC20Metadata{Name: "token", Symbol: "TKN", Owner: "owner", TotalSupply: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	approvalKey, err := stub.CreateCompositeKey("approval", []string{"owner", "spender"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub.PutState("token", erc20Bytes)
+	stub.PutState("owner", []byte("700"))
+	stub.PutState("holder", []byte("300"))
+	stub.PutState(approvalKey, []byte("250"))
+}
+
+// initToken runs the init of the upgrade on the mock ledger of the fixture
+func initToken(f *erc20Fixture, tokenName, owner, amount string) *mockledger.Result {
+	res, err := f.ledger.Init(mockledger.Transaction{Function: "init", Args: []string{tokenName, "TKN", owner, amount}})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	return res
+}
+
+func upgradeStatus(t *testing.T, f *erc20Fixture) model.UpgradeStatus {
+	status := model.UpgradeStatus{}
+	err := json.Unmarshal(f.mustInvoke("", "upgradeStatus"), &status)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return status
+}
+
+func TestInitRecordsLatestSchemaVersion(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+
+	status := upgradeStatus(t, f)
+	if status.SchemaVersion != controller.LatestSchemaVersion || len(status.PendingMigrations) != 0 {
+		t.Fatalf("fresh deployment must be on the latest schema version, got %+v", status)
+	}
+	if status.TokenName != "token" {
+		t.Fatalf("expected token name token, got %s", status.TokenName)
+	}
+}
+
+func TestUpgradeKeepsBalances(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	f.mustInvoke("", "transfer", "owner", "holder", "300")
+
+	// the upgrade passes the initial amount again
+	if res := initToken(f, "token", "owner", "1000"); res.Status != shim.OK {
+		t.Fatal("upgrade failed", res.Message)
+	}
+
+	f.expectBalance("owner", 700)
+	f.expectBalance("holder", 300)
+	if totalSupply := string(f.mustInvoke("", "totalSupply", "token")); totalSupply != "1000" {
+		t.Fatalf("expected total supply 1000, got %s", totalSupply)
+	}
+}
+
+func TestUpgradeFromLegacyLayout(t *testing.T) {
+	f := &erc20Fixture{t: t, ledger: mockledger.New()}
+	stub := f.ledger.MockStub()
+	putLegacyState(t, stub)
+
+	status := upgradeStatus(t, f)
+	if status.SchemaVersion != 0 || len(status.PendingMigrations) != controller.LatestSchemaVersion {
+		t.Fatalf("legacy state must be on the schema version 0, got %+v", status)
+	}
+
+	res := initToken(f, "token", "owner", "1000")
+	if res.Status != shim.OK {
+		t.Fatal("upgrade failed", res.Message)
+	}
+
+	status = upgradeStatus(t, f)
+	if status.SchemaVersion != controller.LatestSchemaVersion || len(status.PendingMigrations) != 0 {
+		t.Fatalf("upgraded state must be on the latest schema version, got %+v", status)
+	}
+	if status.LastUpgradeTxID != res.TxID {
+		t.Fatalf("expected the upgrade tx, got %s", status.LastUpgradeTxID)
+	}
+
+	f.expectBalance("owner", 700)
+	f.expectBalance("holder", 300)
+	f.expectAllowance("owner", "spender", 250)
+
+	// the allowance is migrated to the record
+	approvalKey, _ := stub.CreateCompositeKey("approval", []string{"owner", "spender"})
+	allowance := model.Allowance{}
+	err := json.Unmarshal(stub.State[approvalKey], &allowance)
+	if err != nil || allowance.Amount != 250 {
+		t.Fatalf("allowance must be migrated to the record, got %s", stub.State[approvalKey])
+	}
+}
+
+func TestUpgradeFromLegacyLayoutRejectsOtherToken(t *testing.T) {
+	f := &erc20Fixture{t: t, ledger: mockledger.New()}
+	stub := f.ledger.MockStub()
+	putLegacyState(t, stub)
+
+	// the legacy state is found without the metadata of the token name
+	if status := upgradeStatus(t, f); status.TokenName != "token" {
+		t.Fatalf("expected the legacy token name token, got %+v", status)
+	}
+
+	res := initToken(f, "other", "attacker", "1000000")
+	if res.Status == shim.OK {
+		t.Fatal("upgrade of the legacy state with another token must fail")
+	}
+	if res.Message != "the chaincode is deployed for the token token" {
+		t.Fatalf("expected the token of the legacy state, got %s", res.Message)
+	}
+
+	f.expectBalance("owner", 700)
+	if balance, ok := stub.State["attacker"]; ok {
+		t.Fatalf("rejected upgrade must not credit the owner of the other token, got %s", balance)
+	}
+	if status := upgradeStatus(t, f); status.SchemaVersion != 0 {
+		t.Fatalf("rejected upgrade must not record the schema version, got %+v", status)
+	}
+}
+
+func TestUpgradeIsIdempotent(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+	f.mustInvoke("", "transfer", "owner", "holder", "100")
+	initTxID := upgradeStatus(t, f).LastUpgradeTxID
+
+	for i := 0; i < 2; i++ {
+		if res := initToken(f, "token", "owner", "1000"); res.Status != shim.OK {
+			t.Fatal("upgrade failed", res.Message)
+		}
+	}
+
+	f.expectBalance("owner", 900)
+	if status := upgradeStatus(t, f); status.LastUpgradeTxID != initTxID {
+		t.Fatalf("up to date schema must not be rewritten, got %+v", status)
+	}
+}
+
+func TestUpgradeRejectsOtherToken(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+
+	if res := initToken(f, "other", "attacker", "1000000"); res.Status == shim.OK {
+		t.Fatal("upgrade with another token must fail")
+	}
+
+	f.expectBalance("owner", 1000)
+}
+
+func TestUpgradeRejectsNewerSchema(t *testing.T) {
+	f := newERC20Fixture(t, "owner", 1000)
+
+	stub := f.ledger.MockStub()
+	stub.MockTransactionStart("future")
+	schemaVersionKey, _ := stub.CreateCompositeKey("schemaVersion", []string{})
+	schemaVersionBytes, _ := json.Marshal(model.SchemaVersion{Version: controller.LatestSchemaVersion + 1, TokenName: "token"})
+	stub.PutState(schemaVersionKey, schemaVersionBytes)
+	stub.MockTransactionEnd("future")
+
+	if res := initToken(f, "token", "owner", "1000"); res.Status == shim.OK {
+		t.Fatal("downgrade must fail")
+	}
+}
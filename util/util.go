@@ -24,3 +24,23 @@ func ConverToPositive(value, targetName string) (int, error) {
 
 	return amountInt, nil
 }
+
+// ConvertToNonNegative is ...
+func ConvertToNonNegative(value, targetName string) (int, error) {
+	amountInt, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: targetName,
+			Message:    "must be integer"}
+	}
+
+	if amountInt < 0 {
+		return 0, &model.CustomError{
+			ErrorType:  model.ConvertErrorType,
+			TargetName: targetName,
+			Message:    "cannot be negative"}
+	}
+
+	return amountInt, nil
+}